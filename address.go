@@ -1,6 +1,9 @@
 package goswyftx
 
-import "strconv"
+import (
+	"errors"
+	"strconv"
+)
 
 type AddressDetails struct {
 }
@@ -19,16 +22,16 @@ type Address struct {
 
 type BSBStatus struct {
 	// Duration in milliseconds
-	Duration          int    `json:"durationMs,omitempty"`
-	Status            string `json:"status,omitempty"`
-	StatusDescription string `json:"statusDescription,omitempty"`
-	Address           string `json:"address,omitempty"`
-	BankCode          string `json:"bankCode,omitempty"`
-	BSB               string `json:"bsb,omitempty"`
-	City              string `json:"city,omitempty"`
-	Closed            bool   `json:"closed,omitempty"`
-	PostCode          string `json:"postCode,omitempty"`
-	State             string `json:"state,omitempty"`
+	Duration          int           `json:"durationMs,omitempty"`
+	Status            BSBStatusCode `json:"status,omitempty"`
+	StatusDescription string        `json:"statusDescription,omitempty"`
+	Address           string        `json:"address,omitempty"`
+	BankCode          string        `json:"bankCode,omitempty"`
+	BSB               string        `json:"bsb,omitempty"`
+	City              string        `json:"city,omitempty"`
+	Closed            bool          `json:"closed,omitempty"`
+	PostCode          string        `json:"postCode,omitempty"`
+	State             string        `json:"state,omitempty"`
 }
 
 // AddressService holds methods that can interact with Swyftx address endpoints
@@ -70,6 +73,39 @@ func (as *AddressService) Create(name string) (*Address, error) {
 	return addresses[0], nil
 }
 
+// Save will save a new withdrawal address for an asset and return it, destTag can be empty for
+// assets that don't use one
+func (as *AddressService) Save(name, address, destTag string) (*Address, error) {
+	if isEmptyStr(as.assetCode) {
+		return nil, errAssetCode
+	}
+
+	var (
+		addresses []*Address
+		body      struct {
+			Address struct {
+				Name    string `json:"name"`
+				Details struct {
+					Address string `json:"address"`
+					DestTag string `json:"dest_tag,omitempty"`
+				} `json:"address_details"`
+			} `json:"address"`
+		}
+	)
+	body.Address.Name = name
+	body.Address.Details.Address = address
+	body.Address.Details.DestTag = destTag
+
+	if err := as.client.Post(buildString("address/withdraw/", as.assetCode), &body, &addresses); err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		return nil, errors.New("no address was saved")
+	}
+
+	return addresses[0], nil
+}
+
 // GetActive will get all active addresses for an asset
 func (as *AddressService) GetActive() ([]*Address, error) {
 	return as.getAddresses("deposit")
@@ -111,8 +147,13 @@ func (as *AddressService) VerifyWithdrawal(token string) error {
 	return nil
 }
 
-// VerifyBSB will verify a BSB number and send back the current status of that BSB
+// VerifyBSB will verify a BSB number and send back the current status of that BSB, the format
+// of the BSB is checked before making a request
 func (as *AddressService) VerifyBSB(bsb string) (*BSBStatus, error) {
+	if _, err := ParseBSB(bsb); err != nil {
+		return nil, err
+	}
+
 	var bsbStatus BSBStatus
 	if err := as.client.Get(buildString("address/withdraw/bsb-verify/", bsb), &bsbStatus); err != nil {
 		return nil, err
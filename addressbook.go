@@ -0,0 +1,413 @@
+package goswyftx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrAddressBookPassphrase is returned when an address book can't be decrypted, usually
+// because the passphrase is wrong
+var ErrAddressBookPassphrase = errors.New("could not decrypt address book, wrong passphrase?")
+
+const (
+	addressBookVersion    = 1
+	addressBookKDF        = "pbkdf2-sha256"
+	addressBookIterations = 200000
+	// addressBookMaxIterations is the most iterations a loaded book can ask for
+	addressBookMaxIterations = 10 * addressBookIterations
+	addressBookSaltSize      = 16
+)
+
+// AddressBookEntry is a withdrawal address in an address book
+type AddressBookEntry struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	DestTag string `json:"dest_tag,omitempty"`
+}
+
+// AddressBook declares the withdrawal addresses that should be saved for each asset. Every
+// asset in the book is managed, an asset with no entries has all of its saved addresses
+// removed, while assets not in the book are left alone. Asset codes are case insensitive,
+// entries under codes that only differ by case are merged
+type AddressBook struct {
+	Assets map[string][]*AddressBookEntry `json:"assets"`
+}
+
+// addressBookFile is how an address book is stored, the book is encrypted with AES-256-GCM
+// using a key derived from the passphrase
+type addressBookFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadAddressBook will read and decrypt an address book
+func LoadAddressBook(path, passphrase string) (*AddressBook, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file addressBookFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse address book: %s", err.Error())
+	}
+	if file.Version != addressBookVersion || file.KDF != addressBookKDF {
+		return nil, fmt.Errorf("unsupported address book version %d using %q", file.Version,
+			file.KDF)
+	}
+	// the count comes from the file so a huge one could make loading take forever
+	if file.Iterations <= 0 || file.Iterations > addressBookMaxIterations {
+		return nil, fmt.Errorf("address book has %d key derivation iterations, expected 1 to %d",
+			file.Iterations, addressBookMaxIterations)
+	}
+
+	gcm, err := addressBookCipher(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("address book has an invalid nonce")
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrAddressBookPassphrase
+	}
+
+	var book AddressBook
+	if err = json.Unmarshal(plaintext, &book); err != nil {
+		return nil, fmt.Errorf("could not parse address book: %s", err.Error())
+	}
+	book.Assets = book.assets()
+
+	return &book, nil
+}
+
+// assets will return the book's entries keyed by upper case asset code, merging codes that
+// only differ by case and dropping entries whose address and destination tag are repeated.
+// Addresses are compared by their addressKey so the case of formats that ignore it doesn't
+// matter
+func (b *AddressBook) assets() map[string][]*AddressBookEntry {
+	codes := make([]string, 0, len(b.Assets))
+	for code := range b.Assets {
+		codes = append(codes, code)
+	}
+	// merge in a fixed order so the entries of a merged asset don't depend on map order
+	sort.Strings(codes)
+
+	assets := make(map[string][]*AddressBookEntry, len(b.Assets))
+	for _, code := range codes {
+		upper := strings.ToUpper(code)
+		merged, ok := assets[upper]
+		if !ok {
+			merged = []*AddressBookEntry{}
+		}
+		for _, entry := range b.Assets[code] {
+			duplicate := false
+			for _, other := range merged {
+				if addressKey(upper, other.Address) == addressKey(upper, entry.Address) &&
+					other.DestTag == entry.DestTag {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				merged = append(merged, entry)
+			}
+		}
+		assets[upper] = merged
+	}
+
+	return assets
+}
+
+// Save will encrypt the address book with a fresh salt and nonce and write it to path,
+// replacing the file atomically
+func (b *AddressBook) Save(path, passphrase string) error {
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("could not encode address book: %s", err.Error())
+	}
+
+	file := addressBookFile{
+		Version:    addressBookVersion,
+		KDF:        addressBookKDF,
+		Iterations: addressBookIterations,
+		Salt:       make([]byte, addressBookSaltSize),
+	}
+	if _, err = io.ReadFull(rand.Reader, file.Salt); err != nil {
+		return err
+	}
+	gcm, err := addressBookCipher(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(&file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode address book: %s", err.Error())
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("could not save address book: %s", err.Error())
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("could not save address book: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Allowlist will return the book's addresses as a withdrawal policy allowlist
+func (b *AddressBook) Allowlist() []AllowedAddress {
+	var allowlist []AllowedAddress
+	for asset, entries := range b.assets() {
+		for _, entry := range entries {
+			allowlist = append(allowlist, AllowedAddress{
+				Asset:   asset,
+				Address: entry.Address,
+				DestTag: entry.DestTag,
+			})
+		}
+	}
+
+	return allowlist
+}
+
+// AddressSyncChange is what has to change for an asset's saved addresses to match the book
+type AddressSyncChange struct {
+	Asset  string
+	Add    []*AddressBookEntry
+	Remove []*Address
+}
+
+// AddressSyncPlan is every change needed to sync an address book, ordered by asset code
+type AddressSyncPlan struct {
+	Changes []*AddressSyncChange
+}
+
+// Empty reports whether the saved addresses already match the book
+func (p *AddressSyncPlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String will describe the plan's additions and removals one per line for review
+func (p *AddressSyncPlan) String() string {
+	if p.Empty() {
+		return "address book is in sync\n"
+	}
+
+	var b strings.Builder
+	for _, change := range p.Changes {
+		for _, entry := range change.Add {
+			fmt.Fprintf(&b, "+ %s %s %s", change.Asset, entry.Name, entry.Address)
+			if entry.DestTag != "" {
+				fmt.Fprintf(&b, " tag %s", entry.DestTag)
+			}
+			b.WriteString("\n")
+		}
+		for _, address := range change.Remove {
+			fmt.Fprintf(&b, "- %s %s %s", change.Asset, address.Name, address.Details.Address)
+			if address.Details.DestTag != "" {
+				fmt.Fprintf(&b, " tag %s", address.Details.DestTag)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// Plan will validate every address in the book and compare the book with the saved
+// withdrawal addresses of each asset in it. Addresses match on their address and destination
+// tag, names are not compared and the case of an address only matters for formats that use
+// it, such as base58
+func (b *AddressBook) Plan(c *Client) (*AddressSyncPlan, error) {
+	book := b.assets()
+	codes := make([]string, 0, len(book))
+	for code := range book {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	plan := &AddressSyncPlan{}
+	for _, code := range codes {
+		entries := book[code]
+		for _, entry := range entries {
+			if err := ValidateAddress(code, entry.Address, entry.DestTag); err != nil {
+				return nil, fmt.Errorf("%s address %s: %w", code, entry.Name, err)
+			}
+		}
+
+		saved, err := c.Address(code).GetSaved()
+		if err != nil {
+			return nil, fmt.Errorf("could not get saved %s addresses: %s", code, err.Error())
+		}
+
+		if change := planAddressChange(code, entries, saved); change != nil {
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	return plan, nil
+}
+
+// planAddressChange will compare the book's entries for an asset with its saved addresses, it
+// returns nil if they already match. Each saved address can only match one entry so saved
+// duplicates are removed
+func planAddressChange(code string, entries []*AddressBookEntry,
+	saved []*Address) *AddressSyncChange {
+	change := &AddressSyncChange{Asset: code}
+	matched := make(map[int]bool, len(saved))
+	for _, entry := range entries {
+		found := false
+		key := addressKey(code, entry.Address)
+		for _, address := range saved {
+			if !matched[address.ID] && addressKey(code, address.Details.Address) == key &&
+				address.Details.DestTag == entry.DestTag {
+				matched[address.ID], found = true, true
+				break
+			}
+		}
+		if !found {
+			change.Add = append(change.Add, entry)
+		}
+	}
+	for _, address := range saved {
+		if !matched[address.ID] {
+			change.Remove = append(change.Remove, address)
+		}
+	}
+
+	if len(change.Add) == 0 && len(change.Remove) == 0 {
+		return nil
+	}
+
+	return change
+}
+
+// Sync will plan the changes needed to match the book and, if confirm returns true, apply
+// them. Addresses are added before any are removed so a failure never leaves an asset with
+// fewer addresses than it should have. The plan is returned even if it wasn't applied
+func (b *AddressBook) Sync(c *Client, confirm func(plan *AddressSyncPlan) (bool, error)) (
+	*AddressSyncPlan, error) {
+	if confirm == nil {
+		return nil, errors.New("a confirmation callback is needed to sync an address book")
+	}
+
+	plan, err := b.Plan(c)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Empty() {
+		return plan, nil
+	}
+
+	ok, err := confirm(plan)
+	if err != nil {
+		return plan, fmt.Errorf("could not confirm address book sync: %s", err.Error())
+	}
+	if !ok {
+		return plan, nil
+	}
+
+	for _, change := range plan.Changes {
+		for _, entry := range change.Add {
+			if _, err = c.Address(change.Asset).Save(entry.Name, entry.Address,
+				entry.DestTag); err != nil {
+				return plan, fmt.Errorf("could not save %s address %s: %s", change.Asset,
+					entry.Name, err.Error())
+			}
+		}
+	}
+	for _, change := range plan.Changes {
+		for _, address := range change.Remove {
+			if err = c.Address(change.Asset).Remove(address.ID); err != nil {
+				return plan, fmt.Errorf("could not remove %s address %d: %s", change.Asset,
+					address.ID, err.Error())
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+func addressBookCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("address book passphrase can not be empty")
+	}
+	if len(salt) < addressBookSaltSize {
+		return nil, errors.New("address book salt is too short")
+	}
+
+	key := pbkdf2SHA256([]byte(passphrase), salt, iterations, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 derives a key from a password as described in RFC 8018 using HMAC-SHA256
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	blocks := (keyLen + prf.Size() - 1) / prf.Size()
+
+	key := make([]byte, 0, blocks*prf.Size())
+	var counter [4]byte
+	u := make([]byte, prf.Size())
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter[:])
+		u = prf.Sum(u[:0])
+
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+
+	return key[:keyLen]
+}
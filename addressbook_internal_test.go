@@ -0,0 +1,179 @@
+package goswyftx
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// test vectors from RFC 7914 section 11
+	tests := []struct {
+		password, salt string
+		iterations     int
+		key            string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+			"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b" +
+			"34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+
+	for _, test := range tests {
+		key := pbkdf2SHA256([]byte(test.password), []byte(test.salt), test.iterations, 64)
+		if got := hex.EncodeToString(key); got != test.key {
+			t.Errorf("%s %s: expected %s, got %s", test.password, test.salt, test.key, got)
+		}
+	}
+
+	if key := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 20); hex.EncodeToString(key) !=
+		tests[0].key[:40] {
+		t.Errorf("expected a truncated key, got %x", key)
+	}
+}
+
+func savedAddress(id int, address, destTag string) *Address {
+	a := &Address{ID: id, Name: "saved"}
+	a.Details.Address, a.Details.DestTag = address, destTag
+	return a
+}
+
+func TestPlanAddressChange(t *testing.T) {
+	const btc = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	const xrp = "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh"
+
+	tests := []struct {
+		name    string
+		entries []*AddressBookEntry
+		saved   []*Address
+		add     []string
+		remove  []int
+	}{
+		{
+			name:    "in sync",
+			entries: []*AddressBookEntry{{Name: "cold", Address: btc}},
+			saved:   []*Address{savedAddress(1, btc, "")},
+		},
+		{
+			name:    "duplicate saved addresses",
+			entries: []*AddressBookEntry{{Name: "cold", Address: btc}},
+			saved:   []*Address{savedAddress(1, btc, ""), savedAddress(2, btc, "")},
+			remove:  []int{2},
+		},
+		{
+			name:    "destination tag mismatch",
+			entries: []*AddressBookEntry{{Name: "exchange", Address: xrp, DestTag: "42"}},
+			saved:   []*Address{savedAddress(3, xrp, "43")},
+			add:     []string{"exchange"},
+			remove:  []int{3},
+		},
+		{
+			name:    "new and removed",
+			entries: []*AddressBookEntry{{Name: "new", Address: btc}},
+			saved:   []*Address{savedAddress(4, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "")},
+			add:     []string{"new"},
+			remove:  []int{4},
+		},
+		{
+			name:   "no entries removes everything",
+			saved:  []*Address{savedAddress(5, btc, ""), savedAddress(6, xrp, "1")},
+			remove: []int{5, 6},
+		},
+	}
+
+	for _, test := range tests {
+		change := planAddressChange("BTC", test.entries, test.saved)
+		if len(test.add) == 0 && len(test.remove) == 0 {
+			if change != nil {
+				t.Errorf("%s: expected no change, got %+v", test.name, change)
+			}
+			continue
+		}
+		if change == nil {
+			t.Errorf("%s: expected a change", test.name)
+			continue
+		}
+
+		var add []string
+		for _, entry := range change.Add {
+			add = append(add, entry.Name)
+		}
+		var remove []int
+		for _, address := range change.Remove {
+			remove = append(remove, address.ID)
+		}
+		if !reflect.DeepEqual(add, test.add) || !reflect.DeepEqual(remove, test.remove) {
+			t.Errorf("%s: expected to add %v and remove %v, got %v and %v", test.name, test.add,
+				test.remove, add, remove)
+		}
+	}
+}
+
+func TestAddressBookAssets(t *testing.T) {
+	const btc = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	book := &AddressBook{Assets: map[string][]*AddressBookEntry{
+		"btc": {
+			{Name: "cold", Address: btc},
+			{Name: "other", Address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+		},
+		"BTC": {{Name: "cold again", Address: btc}},
+		"eth": {},
+	}}
+
+	assets := book.assets()
+	if len(assets) != 2 {
+		t.Fatalf("expected BTC and ETH, got %v", assets)
+	}
+	// BTC sorts before btc so its entry is kept over the duplicate
+	if entries := assets["BTC"]; len(entries) != 2 || entries[0].Name != "cold again" ||
+		entries[1].Name != "other" {
+		t.Errorf("expected the BTC entries to be merged, got %+v", entries)
+	}
+	if entries, ok := assets["ETH"]; !ok || len(entries) != 0 {
+		t.Errorf("expected ETH to be kept with no entries, got %v", entries)
+	}
+	if allowlist := book.Allowlist(); len(allowlist) != 2 {
+		t.Errorf("expected 2 allowed addresses, got %d", len(allowlist))
+	}
+}
+
+func TestPlanAddressChangeIgnoresCase(t *testing.T) {
+	tests := []struct {
+		code, book, saved string
+		same              bool
+	}{
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"USDT", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+			"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"BTC", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+			"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", true},
+		{"BCH", "BITCOINCASH:QPM2QSZNHKS23Z7629MMS6S4CWEF74VCWVY22GDX6A",
+			"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", true},
+		// base58 addresses depend on case
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+			"1a1zp1ep5qgefi2dmptftl5slmv7divfna", false},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh",
+			"rhb9cjawyb4rj91vrwn96dkukg4bwdtyth", false},
+	}
+
+	for _, test := range tests {
+		change := planAddressChange(test.code, []*AddressBookEntry{{Name: "book",
+			Address: test.book}}, []*Address{savedAddress(1, test.saved, "")})
+		if test.same && change != nil {
+			t.Errorf("%s %s: expected no change, got %+v", test.code, test.book, change)
+		}
+		if !test.same && change == nil {
+			t.Errorf("%s %s: expected the saved address to be replaced", test.code, test.book)
+		}
+
+		book := &AddressBook{Assets: map[string][]*AddressBookEntry{
+			test.code: {{Name: "a", Address: test.book}, {Name: "b", Address: test.saved}},
+		}}
+		if entries := book.assets()[test.code]; test.same && len(entries) != 1 ||
+			!test.same && len(entries) != 2 {
+			t.Errorf("%s %s: unexpected entries after dedupe %+v", test.code, test.book,
+				entries)
+		}
+	}
+}
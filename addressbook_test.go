@@ -0,0 +1,92 @@
+package goswyftx_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func TestAddressBookSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addressbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "addresses.json")
+
+	book := &goswyftx.AddressBook{Assets: map[string][]*goswyftx.AddressBookEntry{
+		"BTC": {{Name: "cold", Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}},
+		"XRP": {{Name: "exchange", Address: "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", DestTag: "42"}},
+	}}
+	if err = book.Save(path, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa") {
+		t.Error("expected the address book to be encrypted")
+	}
+
+	if _, err = goswyftx.LoadAddressBook(path, "wrong horse"); !errors.Is(err,
+		goswyftx.ErrAddressBookPassphrase) {
+		t.Errorf("expected a passphrase error, got %v", err)
+	}
+
+	loaded, err := goswyftx.LoadAddressBook(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Assets) != 2 || loaded.Assets["XRP"][0].DestTag != "42" {
+		t.Errorf("unexpected address book: %+v", loaded.Assets)
+	}
+	if allowlist := loaded.Allowlist(); len(allowlist) != 2 {
+		t.Errorf("expected 2 allowed addresses, got %d", len(allowlist))
+	}
+}
+
+func TestLoadAddressBookIterations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addressbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "addresses.json")
+
+	book := &goswyftx.AddressBook{Assets: map[string][]*goswyftx.AddressBookEntry{}}
+	if err = book.Save(path, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file map[string]interface{}
+	if err = json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	// a tampered count is rejected before any key derivation is done
+	for _, iterations := range []int{0, -1, 1 << 40} {
+		file["iterations"] = iterations
+		if data, err = json.Marshal(file); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = goswyftx.LoadAddressBook(path, "correct horse"); err == nil ||
+			errors.Is(err, goswyftx.ErrAddressBookPassphrase) {
+			t.Errorf("%d: expected an iterations error, got %v", iterations, err)
+		}
+	}
+}
@@ -0,0 +1,516 @@
+package goswyftx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Errors returned when validating an address
+var (
+	ErrInvalidAddress  = errors.New("invalid address")
+	ErrDestTagRequired = errors.New("destination tag is required")
+)
+
+// AddressValidator checks the format of an address and its destination tag for an asset
+type AddressValidator func(address, destTag string) error
+
+var (
+	addressValidatorsMu sync.RWMutex
+	addressValidators   = map[string]AddressValidator{
+		"BTC":  bitcoinValidator("bc", 0x00, 0x05),
+		"LTC":  bitcoinValidator("ltc", 0x30, 0x32, 0x05),
+		"BCH":  bitcoinCashValidator(base58CheckValidator(bitcoinAlphabet, 0x00, 0x05)),
+		"DOGE": base58CheckValidator(bitcoinAlphabet, 0x1e, 0x16),
+		"DASH": base58CheckValidator(bitcoinAlphabet, 0x4c, 0x10),
+		"TRX":  base58CheckValidator(bitcoinAlphabet, 0x41),
+		"XRP":  validateXRP,
+		"ETH":  validateEthereum,
+	}
+)
+
+// erc20Assets are tokens that are withdrawn to an ethereum address
+var erc20Assets = []string{"USDT", "USDC", "DAI", "LINK", "UNI", "AAVE", "COMP", "MKR", "SNX",
+	"YFI", "BAT", "ZRX", "OMG", "MATIC", "SUSHI", "GRT", "ENJ", "MANA", "SAND", "SHIB", "CRV"}
+
+func init() {
+	for _, code := range erc20Assets {
+		addressValidators[code] = validateEthereum
+	}
+}
+
+// RegisterAddressValidator will set the validator used for an asset code, replacing any
+// built in validator
+func RegisterAddressValidator(assetCode string, validator AddressValidator) {
+	addressValidatorsMu.Lock()
+	defer addressValidatorsMu.Unlock()
+
+	addressValidators[strings.ToUpper(assetCode)] = validator
+}
+
+// ValidateAddress will check the format of an address for an asset offline. Errors wrap
+// ErrInvalidAddress or ErrDestTagRequired. Assets without a known format are not checked and
+// return nil, use CanValidateAddress to tell them apart
+func ValidateAddress(assetCode, address, destTag string) error {
+	addressValidatorsMu.RLock()
+	validator, ok := addressValidators[strings.ToUpper(assetCode)]
+	addressValidatorsMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	return validator(strings.TrimSpace(address), strings.TrimSpace(destTag))
+}
+
+// CanValidateAddress will report whether ValidateAddress knows the address format of an asset
+func CanValidateAddress(assetCode string) bool {
+	addressValidatorsMu.RLock()
+	defer addressValidatorsMu.RUnlock()
+
+	_, ok := addressValidators[strings.ToUpper(assetCode)]
+	return ok
+}
+
+// addressKey will return the form of an address used to tell whether two addresses are the
+// same. Ethereum, bech32 and CashAddr addresses don't depend on case so they are lower cased
+// and CashAddr's optional prefix is dropped, other formats such as base58 are case sensitive
+// and kept as they are
+func addressKey(assetCode, address string) string {
+	address = strings.TrimSpace(address)
+	lower := strings.ToLower(address)
+
+	switch code := strings.ToUpper(assetCode); {
+	case code == "ETH" || containsString(erc20Assets, code):
+		if strings.HasPrefix(lower, "0x") {
+			return lower
+		}
+	case code == "BTC" && strings.HasPrefix(lower, "bc1"),
+		code == "LTC" && strings.HasPrefix(lower, "ltc1"):
+		return lower
+	case code == "BCH":
+		if strings.HasPrefix(lower, cashAddrPrefix+":") || strings.HasPrefix(lower, "q") ||
+			strings.HasPrefix(lower, "p") {
+			return strings.TrimPrefix(lower, cashAddrPrefix+":")
+		}
+	}
+
+	return address
+}
+
+// Validate will check the format of the address for its asset code
+func (a *Address) Validate() error {
+	return ValidateAddress(a.Code, a.Details.Address, a.Details.DestTag)
+}
+
+func invalidAddress(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidAddress, fmt.Sprintf(format, args...))
+}
+
+// bitcoinValidator accepts segwit addresses with the human readable part hrp or base58check
+// addresses with one of the version bytes
+func bitcoinValidator(hrp string, versions ...byte) AddressValidator {
+	legacy := base58CheckValidator(bitcoinAlphabet, versions...)
+
+	return func(address, destTag string) error {
+		if strings.HasPrefix(strings.ToLower(address), hrp+"1") {
+			return validateSegwit(hrp, address)
+		}
+
+		return legacy(address, destTag)
+	}
+}
+
+// base58CheckValidator accepts base58check addresses with a 20 byte hash and one of the
+// version bytes
+func base58CheckValidator(alphabet string, versions ...byte) AddressValidator {
+	return func(address, destTag string) error {
+		payload, err := decodeBase58Check(alphabet, address)
+		if err != nil {
+			return err
+		}
+		if len(payload) != 21 {
+			return invalidAddress("expected 21 bytes, got %d", len(payload))
+		}
+		if bytes.IndexByte(versions, payload[0]) < 0 {
+			return invalidAddress("unexpected version byte 0x%02x", payload[0])
+		}
+
+		return nil
+	}
+}
+
+func validateXRP(address, destTag string) error {
+	if !strings.HasPrefix(address, "r") {
+		return invalidAddress("XRP addresses start with r")
+	}
+	if err := base58CheckValidator(rippleAlphabet, 0x00)(address, ""); err != nil {
+		return err
+	}
+
+	if destTag == "" {
+		return ErrDestTagRequired
+	}
+	if _, err := strconv.ParseUint(destTag, 10, 32); err != nil {
+		return fmt.Errorf("%w: destination tag must be a number up to %d", ErrInvalidAddress,
+			uint32(1<<32-1))
+	}
+
+	return nil
+}
+
+// validateEthereum accepts 0x prefixed 20 byte hex addresses. Mixed case addresses must have
+// a valid EIP-55 checksum, all lower or upper case addresses have no checksum to check
+func validateEthereum(address, destTag string) error {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return invalidAddress("expected 0x followed by 40 hex characters")
+	}
+	hexAddress := address[2:]
+	if _, err := hex.DecodeString(hexAddress); err != nil {
+		return invalidAddress("expected 0x followed by 40 hex characters")
+	}
+
+	lower := strings.ToLower(hexAddress)
+	if hexAddress == lower || hexAddress == strings.ToUpper(hexAddress) {
+		return nil
+	}
+	if hexAddress != eip55(lower) {
+		return invalidAddress("EIP-55 checksum mismatch")
+	}
+
+	return nil
+}
+
+// eip55 will capitalise the letters of a lower case hex address whose nibble in the keccak
+// hash of the address is 8 or more
+func eip55(lower string) string {
+	hash := keccak256([]byte(lower))
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && c <= 'f' && nibble >= 8 {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+
+	return string(checksummed)
+}
+
+const (
+	bitcoinAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	rippleAlphabet  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+)
+
+// decodeBase58Check will decode a base58 string and check and remove its four byte double
+// SHA-256 checksum
+func decodeBase58Check(alphabet, s string) ([]byte, error) {
+	if s == "" {
+		return nil, invalidAddress("empty address")
+	}
+
+	var decoded []byte
+	for i := 0; i < len(s); i++ {
+		carry := strings.IndexByte(alphabet, s[i])
+		if carry < 0 {
+			return nil, invalidAddress("invalid base58 character %q", s[i])
+		}
+		for j := len(decoded) - 1; j >= 0; j-- {
+			carry += int(decoded[j]) * 58
+			decoded[j] = byte(carry)
+			carry >>= 8
+		}
+		for ; carry > 0; carry >>= 8 {
+			decoded = append([]byte{byte(carry)}, decoded...)
+		}
+	}
+	// leading zero characters are leading zero bytes
+	for i := 0; i < len(s) && s[i] == alphabet[0]; i++ {
+		decoded = append([]byte{0}, decoded...)
+	}
+
+	if len(decoded) < 5 {
+		return nil, invalidAddress("too short")
+	}
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, invalidAddress("checksum mismatch")
+	}
+
+	return payload, nil
+}
+
+const cashAddrPrefix = "bitcoincash"
+
+// bitcoinCashValidator accepts CashAddr addresses, with or without their bitcoincash: prefix,
+// or legacy addresses
+func bitcoinCashValidator(legacy AddressValidator) AddressValidator {
+	return func(address, destTag string) error {
+		lower := strings.ToLower(address)
+		if strings.HasPrefix(lower, cashAddrPrefix+":") || strings.HasPrefix(lower, "q") ||
+			strings.HasPrefix(lower, "p") {
+			return validateCashAddr(address)
+		}
+
+		return legacy(address, destTag)
+	}
+}
+
+// validateCashAddr will check a CashAddr address holding a 20 byte P2PKH or P2SH hash
+func validateCashAddr(address string) error {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return invalidAddress("mixed case")
+	}
+	address = strings.ToLower(address)
+	address = strings.TrimPrefix(address, cashAddrPrefix+":")
+
+	// the version byte and hash are 34 characters and the checksum another 8
+	if len(address) != 42 {
+		return invalidAddress("expected 42 characters after %s:", cashAddrPrefix)
+	}
+	data := make([]byte, 0, len(address))
+	for i := 0; i < len(address); i++ {
+		value := strings.IndexByte(bech32Charset, address[i])
+		if value < 0 {
+			return invalidAddress("invalid base32 character %q", address[i])
+		}
+		data = append(data, byte(value))
+	}
+
+	values := make([]byte, 0, len(cashAddrPrefix)+1+len(data))
+	for i := 0; i < len(cashAddrPrefix); i++ {
+		values = append(values, cashAddrPrefix[i]&31)
+	}
+	values = append(values, 0)
+	if cashAddrPolymod(append(values, data...)) != 0 {
+		return invalidAddress("checksum mismatch")
+	}
+
+	payload, ok := convertBits(data[:len(data)-8], 5, 8)
+	if !ok || len(payload) != 21 {
+		return invalidAddress("invalid payload")
+	}
+	// the version byte's type is 0 for P2PKH or 1 for P2SH and its size is 0 for 20 bytes
+	if version := payload[0]; version != 0x00 && version != 0x08 {
+		return invalidAddress("unexpected version byte 0x%02x", version)
+	}
+
+	return nil
+}
+
+func cashAddrPolymod(values []byte) uint64 {
+	generator := [5]uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+
+	chk := uint64(1)
+	for _, value := range values {
+		top := chk >> 35
+		chk = (chk&0x07ffffffff)<<5 ^ uint64(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk ^ 1
+}
+
+const (
+	bech32Charset  = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32Const    = 1
+	bech32mConst   = 0x2bc830a3
+	bech32MaxChars = 90
+)
+
+// validateSegwit will check a bech32 or bech32m segwit address as described in BIP 173 and
+// BIP 350
+func validateSegwit(hrp, address string) error {
+	if len(address) > bech32MaxChars {
+		return invalidAddress("longer than %d characters", bech32MaxChars)
+	}
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return invalidAddress("mixed case")
+	}
+	address = strings.ToLower(address)
+
+	sep := strings.LastIndexByte(address, '1')
+	if sep < 1 || sep+7 > len(address) || address[:sep] != hrp {
+		return invalidAddress("expected %s1 followed by the data and checksum", hrp)
+	}
+
+	data := make([]byte, 0, len(address)-sep-1)
+	for i := sep + 1; i < len(address); i++ {
+		value := strings.IndexByte(bech32Charset, address[i])
+		if value < 0 {
+			return invalidAddress("invalid bech32 character %q", address[i])
+		}
+		data = append(data, byte(value))
+	}
+
+	constant := bech32Polymod(append(bech32ExpandHRP(hrp), data...))
+	if constant != bech32Const && constant != bech32mConst {
+		return invalidAddress("checksum mismatch")
+	}
+	data = data[:len(data)-6]
+	if len(data) == 0 {
+		return invalidAddress("missing witness version")
+	}
+
+	version := data[0]
+	if version > 16 {
+		return invalidAddress("invalid witness version %d", version)
+	}
+	if version == 0 && constant != bech32Const || version != 0 && constant != bech32mConst {
+		return invalidAddress("witness version %d uses the wrong checksum", version)
+	}
+
+	program, ok := convertBits(data[1:], 5, 8)
+	if !ok || len(program) < 2 || len(program) > 40 {
+		return invalidAddress("invalid witness program")
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return invalidAddress("version 0 witness programs are 20 or 32 bytes")
+	}
+
+	return nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, value := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+
+	return expanded
+}
+
+// convertBits will regroup 5 bit groups into bytes, any padding must be zero and less than a
+// group
+func convertBits(data []byte, from, to uint) ([]byte, bool) {
+	var acc, bitCount uint
+	maxValue := uint(1)<<to - 1
+
+	var out []byte
+	for _, value := range data {
+		acc = acc<<from | uint(value)
+		bitCount += from
+		for bitCount >= to {
+			bitCount -= to
+			out = append(out, byte(acc>>bitCount&maxValue))
+		}
+	}
+	if bitCount >= from || acc<<(to-bitCount)&maxValue != 0 {
+		return nil, false
+	}
+
+	return out, true
+}
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+var keccakRotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// keccak256 is the original Keccak hash used by ethereum, which pads differently to the
+// standardised SHA3-256
+func keccak256(data []byte) [32]byte {
+	const rate = 136
+
+	var state [25]uint64
+	padded := make([]byte, len(data), len(data)+rate)
+	copy(padded, data)
+	padded = append(padded, 0x01)
+	for len(padded)%rate != 0 {
+		padded = append(padded, 0)
+	}
+	padded[len(padded)-1] |= 0x80
+
+	for block := 0; block < len(padded); block += rate {
+		for i := 0; i < rate/8; i++ {
+			for j := 0; j < 8; j++ {
+				state[i] ^= uint64(padded[block+i*8+j]) << (8 * uint(j))
+			}
+		}
+		keccakF(&state)
+	}
+
+	var hash [32]byte
+	for i := range hash {
+		hash[i] = byte(state[i/8] >> (8 * uint(i%8)))
+	}
+
+	return hash
+}
+
+// keccakF is the keccak-f[1600] permutation, the state is indexed x + 5y
+func keccakF(a *[25]uint64) {
+	var b [25]uint64
+	var c, d [5]uint64
+	for round := 0; round < 24; round++ {
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d[x] = c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+		}
+		for i := range a {
+			a[i] ^= d[i%5]
+		}
+
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], keccakRotations[x+5*y])
+			}
+		}
+
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				a[y+x] = b[y+x] ^ ^b[y+(x+1)%5]&b[y+(x+2)%5]
+			}
+		}
+
+		a[0] ^= keccakRoundConstants[round]
+	}
+}
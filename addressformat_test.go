@@ -0,0 +1,57 @@
+package goswyftx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		asset   string
+		address string
+		destTag string
+		err     error
+	}{
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", nil},
+		{"btc", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "", nil},
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", "", goswyftx.ErrInvalidAddress},
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "", nil},
+		{"BTC", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "", nil},
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", "", goswyftx.ErrInvalidAddress},
+		{"BTC", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "", nil},
+		// a version 1 program with a bech32 rather than bech32m checksum
+		{"BTC", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", "",
+			goswyftx.ErrInvalidAddress},
+		{"LTC", "LKDyUEtTR1HXamkiEphisSiBJu6o3ZPE34", "", nil},
+		{"LTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", goswyftx.ErrInvalidAddress},
+		{"DOGE", "D5ERdEN1gsouFSs7zsq7VYJxyWP6dP28H1", "", nil},
+		{"BCH", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", "", nil},
+		{"BCH", "BITCOINCASH:QPM2QSZNHKS23Z7629MMS6S4CWEF74VCWVY22GDX6A", "", nil},
+		{"BCH", "qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", "", nil},
+		{"BCH", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq", "", nil},
+		{"BCH", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6b", "",
+			goswyftx.ErrInvalidAddress},
+		{"BCH", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvY22gdx6a", "",
+			goswyftx.ErrInvalidAddress},
+		{"BCH", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", "", nil},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "", nil},
+		{"USDT", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", "", nil},
+		{"ETH", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "", nil},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "", goswyftx.ErrInvalidAddress},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", "", goswyftx.ErrInvalidAddress},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "12345", nil},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "", goswyftx.ErrDestTagRequired},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "4294967296", goswyftx.ErrInvalidAddress},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTj", "1", goswyftx.ErrInvalidAddress},
+		{"UNKNOWN", "anything", "", nil},
+	}
+
+	for _, test := range tests {
+		err := goswyftx.ValidateAddress(test.asset, test.address, test.destTag)
+		if test.err == nil && err != nil || test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%s %s: expected error %v, got %v", test.asset, test.address, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Package alerts watches prices, balances, orders and withdrawals and sends notifications when
+// user defined rules match
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+const (
+	defaultCooldown = time.Hour
+	defaultHistory  = 24 * time.Hour
+)
+
+// Source is data a rule reads, the daemon only fetches the sources its rules need
+type Source int
+
+// Sources of data
+const (
+	Prices Source = 1 << iota
+	Balances
+	Orders
+	Withdrawals
+)
+
+// Alert is a notification raised by a rule
+type Alert struct {
+	// Rule is the name of the rule that raised the alert
+	Rule string `json:"rule"`
+	// Key identifies the alert for de-duplication, alerts with the same key aren't sent again
+	// until the cooldown has passed
+	Key     string    `json:"key"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+	Message string    `json:"message"`
+}
+
+// Rule decides when to raise alerts
+type Rule interface {
+	// Name describes the rule, it is used as the key of its alerts unless they need their own
+	Name() string
+	// Needs is the data the rule reads and the asset code whose orders or withdrawals it
+	// reads, if any
+	Needs() (Source, string)
+	// Check will return the alerts raised by the current state
+	Check(state *State) []*Alert
+}
+
+// Notifier sends alerts somewhere
+type Notifier interface {
+	Notify(ctx context.Context, alert *Alert) error
+}
+
+type pricePoint struct {
+	time  time.Time
+	price float64
+}
+
+// State is everything the daemon fetched in a single check, only the sources rules need are
+// filled in
+type State struct {
+	Time time.Time
+	// Prices are mid prices in the base asset and Balances are available balances, both keyed
+	// by upper case asset code
+	Prices   map[string]float64
+	Balances map[string]float64
+	// Orders and Withdrawals of the assets rules watch, keyed by upper case asset code
+	Orders      map[string][]*goswyftx.Order
+	Withdrawals map[string][]*goswyftx.CurrencyHistory
+	// Previous is the state of the last check, it is nil on the first check
+	Previous *State
+
+	history map[string][]pricePoint
+}
+
+// PriceAt will return the most recent price of an asset seen at or before t
+func (s *State) PriceAt(code string, t time.Time) (float64, bool) {
+	points := s.history[strings.ToUpper(code)]
+	for i := len(points) - 1; i >= 0; i-- {
+		if !points[i].time.After(t) {
+			return points[i].price, true
+		}
+	}
+
+	return 0, false
+}
+
+// Config of a daemon
+type Config struct {
+	// Base is the asset code prices are in e.g. AUD
+	Base      string
+	Rules     []Rule
+	Notifiers []Notifier
+	// Cooldown is the minimum time between two alerts with the same key, defaults to an hour
+	Cooldown time.Duration
+	// History is how long prices are kept for rules that compare against older prices,
+	// defaults to a day
+	History time.Duration
+	// OnError is called when a notifier fails or Run fails to check the rules, alert is nil
+	// when the failure isn't about a single alert. It is optional
+	OnError func(alert *Alert, err error)
+}
+
+// Daemon checks rules against live data and sends their alerts to every notifier
+type Daemon struct {
+	client *goswyftx.Client
+	cfg    Config
+	needs  Source
+	assets map[string]Source
+
+	mu       sync.Mutex
+	previous *State
+	history  map[string][]pricePoint
+	sent     map[string]time.Time
+}
+
+// New will create a daemon that checks rules with client
+func New(client *goswyftx.Client, cfg Config) (*Daemon, error) {
+	if len(cfg.Rules) == 0 {
+		return nil, errors.New("no rules to check")
+	}
+	if len(cfg.Notifiers) == 0 {
+		return nil, errors.New("no notifiers to send alerts to")
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultCooldown
+	}
+	if cfg.History <= 0 {
+		cfg.History = defaultHistory
+	}
+
+	d := &Daemon{
+		client:  client,
+		cfg:     cfg,
+		assets:  make(map[string]Source),
+		history: make(map[string][]pricePoint),
+		sent:    make(map[string]time.Time),
+	}
+	for _, rule := range cfg.Rules {
+		needs, asset := rule.Needs()
+		d.needs |= needs
+		if asset != "" {
+			d.assets[strings.ToUpper(asset)] |= needs
+		}
+	}
+	if d.needs&Prices != 0 && cfg.Base == "" {
+		return nil, errors.New("a base asset is needed to check prices")
+	}
+
+	return d, nil
+}
+
+// Run will check rules every poll interval until ctx is done. Errors fetching data don't stop
+// the daemon, they are passed to OnError and the next check tries again
+func (d *Daemon) Run(ctx context.Context, poll time.Duration) error {
+	if poll <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Check(ctx); err != nil {
+			d.reportError(nil, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check will fetch the data rules need, check every rule and send any alerts that aren't in
+// their cooldown. The alerts sent are returned
+func (d *Daemon) Check(ctx context.Context) ([]*Alert, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, err := d.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := d.evaluate(state)
+	for _, alert := range alerts {
+		for _, notifier := range d.cfg.Notifiers {
+			if err := notifier.Notify(ctx, alert); err != nil {
+				d.reportError(alert, err)
+			}
+		}
+	}
+
+	return alerts, nil
+}
+
+func (d *Daemon) reportError(alert *Alert, err error) {
+	if d.cfg.OnError != nil {
+		d.cfg.OnError(alert, err)
+	}
+}
+
+// Evaluate will check every rule against state and return the alerts not in their cooldown
+// without sending them. The state becomes the previous state of the next check, so it can be
+// used to feed the daemon data fetched some other way
+func (d *Daemon) Evaluate(state *State) []*Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if state.Time.IsZero() {
+		state.Time = time.Now()
+	}
+
+	return d.evaluate(state)
+}
+
+func (d *Daemon) evaluate(state *State) []*Alert {
+	for code, price := range state.Prices {
+		points := append(d.history[code], pricePoint{state.Time, price})
+		cutoff := state.Time.Add(-d.cfg.History)
+		for len(points) > 1 && points[1].time.Before(cutoff) {
+			points = points[1:]
+		}
+		d.history[code] = points
+	}
+	state.history = d.history
+	state.Previous = d.previous
+
+	var alerts []*Alert
+	for _, rule := range d.cfg.Rules {
+		for _, alert := range rule.Check(state) {
+			if alert.Rule == "" {
+				alert.Rule = rule.Name()
+			}
+			if alert.Key == "" {
+				alert.Key = alert.Rule
+			}
+			if alert.Time.IsZero() {
+				alert.Time = state.Time
+			}
+
+			if last, ok := d.sent[alert.Key]; ok && state.Time.Sub(last) < d.cfg.Cooldown {
+				continue
+			}
+			d.sent[alert.Key] = state.Time
+			alerts = append(alerts, alert)
+		}
+	}
+
+	for key, last := range d.sent {
+		if state.Time.Sub(last) >= d.cfg.Cooldown {
+			delete(d.sent, key)
+		}
+	}
+	// only one state back is kept so old states can be collected
+	state.Previous = nil
+	d.previous = state
+
+	return alerts
+}
+
+func (d *Daemon) fetch(ctx context.Context) (*State, error) {
+	client := d.client.WithContext(ctx)
+	state := &State{Time: time.Now()}
+
+	var registry *goswyftx.AssetRegistry
+	if d.needs&(Prices|Balances|Withdrawals) != 0 {
+		var err error
+		if registry, err = client.Market().Registry(); err != nil {
+			return nil, fmt.Errorf("could not get assets: %s", err.Error())
+		}
+	}
+
+	if d.needs&Prices != 0 {
+		base, ok := registry.ByCode(d.cfg.Base)
+		if !ok {
+			return nil, fmt.Errorf("unknown asset: %s", d.cfg.Base)
+		}
+		rates, err := client.Market().Rates(base.ID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get live rates: %s", err.Error())
+		}
+
+		state.Prices = make(map[string]float64, len(rates))
+		for id, rate := range rates {
+			asset, ok := registry.ByID(id)
+			if !ok {
+				continue
+			}
+			price, err := strconv.ParseFloat(rate.MidPrice, 64)
+			if err != nil {
+				// one bad price shouldn't stop every other rule from being checked
+				d.reportError(nil, fmt.Errorf("could not parse price of %s: %s", asset.Code,
+					err.Error()))
+				continue
+			}
+			if price > 0 {
+				state.Prices[strings.ToUpper(asset.Code)] = price
+			}
+		}
+	}
+
+	if d.needs&Balances != 0 {
+		balances, err := client.Account().Balance()
+		if err != nil {
+			return nil, fmt.Errorf("could not get balances: %s", err.Error())
+		}
+
+		state.Balances = make(map[string]float64, len(balances))
+		for _, balance := range balances {
+			asset, ok := registry.ByID(balance.AssetID)
+			if !ok {
+				continue
+			}
+			quantity, err := strconv.ParseFloat(balance.AvailableBalance, 64)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse balance of %s: %s", asset.Code, err.Error())
+			}
+			state.Balances[strings.ToUpper(asset.Code)] = quantity
+		}
+	}
+
+	for code, needs := range d.assets {
+		if needs&Orders != 0 {
+			orders, err := client.Order().List(code)
+			if err != nil {
+				return nil, fmt.Errorf("could not get orders of %s: %s", code, err.Error())
+			}
+			if state.Orders == nil {
+				state.Orders = make(map[string][]*goswyftx.Order)
+			}
+			state.Orders[code] = orders
+		}
+
+		if needs&Withdrawals != 0 {
+			asset, ok := registry.ByCode(code)
+			if !ok {
+				return nil, fmt.Errorf("unknown asset: %s", code)
+			}
+			withdrawals, err := client.History(asset.ID).Withdraw()
+			if err != nil {
+				return nil, fmt.Errorf("could not get withdrawals of %s: %s", code, err.Error())
+			}
+			if state.Withdrawals == nil {
+				state.Withdrawals = make(map[string][]*goswyftx.CurrencyHistory)
+			}
+			state.Withdrawals[code] = withdrawals
+		}
+	}
+
+	return state, nil
+}
@@ -0,0 +1,84 @@
+package alerts_test
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+	"github.com/joshturge/goswyftx/alerts"
+)
+
+func TestDaemonEvaluate(t *testing.T) {
+	daemon, err := alerts.New(nil, alerts.Config{
+		Base: "AUD",
+		Rules: []alerts.Rule{
+			&alerts.PriceCross{Asset: "btc", Level: 100, Direction: alerts.Above},
+			&alerts.PercentChange{Asset: "BTC", Percent: 10, Window: time.Hour},
+			&alerts.OrderFilled{Asset: "BTC"},
+		},
+		Notifiers: []alerts.Notifier{alerts.NewWriterNotifier(ioutil.Discard)},
+		Cooldown:  30 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	order := &goswyftx.Order{ID: 7, Type: goswyftx.OrderTypeMarketBuy,
+		Status: goswyftx.OrderStatusOpen}
+	filled := *order
+	filled.Status = goswyftx.OrderStatusCompleted
+
+	steps := []struct {
+		offset time.Duration
+		price  float64
+		order  *goswyftx.Order
+		want   []string
+	}{
+		{0, 90, order, nil},
+		// crosses 100 and the order fills, but a full window hasn't been seen yet
+		{20 * time.Minute, 105, &filled, []string{"BTC is above 100", "BTC buy order 7 filled"}},
+		// the same order isn't alerted twice and the cross is in its cooldown
+		{40 * time.Minute, 95, &filled, nil},
+		{45 * time.Minute, 101, &filled, nil},
+		// 101 is more than 10% up on the 90 seen an hour ago
+		{time.Hour, 101, &filled, []string{"BTC changed +12.22% in 1h0m0s"}},
+		// out of its cooldown the next cross is alerted again
+		{70 * time.Minute, 99, &filled, nil},
+		{80 * time.Minute, 102, &filled, []string{"BTC is above 100"}},
+	}
+
+	for _, step := range steps {
+		got := daemon.Evaluate(&alerts.State{
+			Time:   start.Add(step.offset),
+			Prices: map[string]float64{"BTC": step.price},
+			Orders: map[string][]*goswyftx.Order{"BTC": {step.order}},
+		})
+
+		if len(got) != len(step.want) {
+			t.Fatalf("at %s: expected %d alerts, got %d", step.offset, len(step.want), len(got))
+		}
+		for i, alert := range got {
+			if alert.Subject != step.want[i] {
+				t.Errorf("at %s: expected alert %q, got %q", step.offset, step.want[i],
+					alert.Subject)
+			}
+		}
+	}
+}
+
+func TestDaemonRunRejectsPoll(t *testing.T) {
+	daemon, err := alerts.New(nil, alerts.Config{
+		Rules:     []alerts.Rule{&alerts.OrderFilled{Asset: "BTC"}},
+		Notifiers: []alerts.Notifier{alerts.NewWriterNotifier(ioutil.Discard)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = daemon.Run(context.Background(), 0); err == nil {
+		t.Error("expected an error for a zero poll interval")
+	}
+}
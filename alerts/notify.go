@@ -0,0 +1,153 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WriterNotifier writes each alert as a line of text
+type WriterNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterNotifier will create a notifier that writes alerts to w
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+// Stdout will create a notifier that writes alerts to standard output
+func Stdout() *WriterNotifier {
+	return NewWriterNotifier(os.Stdout)
+}
+
+// Notify will write the alert
+func (n *WriterNotifier) Notify(ctx context.Context, alert *Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(n.w, "%s [%s] %s: %s\n", alert.Time.Format(time.RFC3339), alert.Rule,
+		alert.Subject, alert.Message)
+	return err
+}
+
+// Webhook posts each alert as JSON to a URL
+type Webhook struct {
+	URL string
+	// Headers are added to every request e.g. an authorization token
+	Headers map[string]string
+	// HTTPClient defaults to a client with a ten second timeout
+	HTTPClient *http.Client
+}
+
+// Notify will post the alert, any response other than 2xx is an error
+func (n *Webhook) Notify(ctx context.Context, alert *Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range n.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := n.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
+
+// SMTP emails each alert through a mail relay, it is meant for a local relay that doesn't need
+// authentication or set Auth for one that does
+type SMTP struct {
+	// Addr of the relay e.g. localhost:25
+	Addr string
+	From string
+	To   []string
+	Auth smtp.Auth
+}
+
+// Notify will send the alert as a plain text email
+func (n *SMTP) Notify(ctx context.Context, alert *Alert) error {
+	if len(n.To) == 0 {
+		return errors.New("no recipients to email")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", stripNewlines(alert.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(alert.Message)
+	msg.WriteString("\r\n")
+
+	return smtp.SendMail(n.Addr, n.Auth, n.From, n.To, msg.Bytes())
+}
+
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// Desktop shows each alert as a desktop notification using notify-send on linux and
+// osascript on macOS
+type Desktop struct{}
+
+// Notify will show the alert, other operating systems return an error
+func (n Desktop) Notify(ctx context.Context, alert *Alert) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", alert.Subject, alert.Message)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s",
+			appleScriptString(alert.Message), appleScriptString(alert.Subject))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	default:
+		return fmt.Errorf("desktop notifications aren't supported on %s", runtime.GOOS)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not show notification: %s: %s", err.Error(),
+			strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
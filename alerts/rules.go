@@ -0,0 +1,249 @@
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+// Direction is which way a price has to move through a level
+type Direction int
+
+// Directions a price can cross a level
+const (
+	Either Direction = iota
+	Above
+	Below
+)
+
+// PriceCross raises an alert when an asset's price moves through a level between two checks
+type PriceCross struct {
+	Asset     string
+	Level     float64
+	Direction Direction
+}
+
+// Name of the rule
+func (r *PriceCross) Name() string {
+	direction := "crosses"
+	switch r.Direction {
+	case Above:
+		direction = "crosses above"
+	case Below:
+		direction = "crosses below"
+	}
+
+	return fmt.Sprintf("%s %s %g", strings.ToUpper(r.Asset), direction, r.Level)
+}
+
+// Needs prices
+func (r *PriceCross) Needs() (Source, string) {
+	return Prices, ""
+}
+
+// Check will raise an alert if the price was on the other side of the level at the last check
+func (r *PriceCross) Check(state *State) []*Alert {
+	if state.Previous == nil {
+		return nil
+	}
+	code := strings.ToUpper(r.Asset)
+	price, ok := state.Prices[code]
+	if !ok {
+		return nil
+	}
+	prev, ok := state.Previous.Prices[code]
+	if !ok {
+		return nil
+	}
+
+	up := prev < r.Level && price >= r.Level
+	down := prev > r.Level && price <= r.Level
+	if !(up && r.Direction != Below || down && r.Direction != Above) {
+		return nil
+	}
+
+	direction := "above"
+	if down {
+		direction = "below"
+	}
+	return []*Alert{{
+		Subject: fmt.Sprintf("%s is %s %g", code, direction, r.Level),
+		Message: fmt.Sprintf("%s moved from %g to %g", code, prev, price),
+	}}
+}
+
+// PercentChange raises an alert when an asset's price has changed by at least Percent over
+// Window, in either direction
+type PercentChange struct {
+	Asset   string
+	Percent float64
+	Window  time.Duration
+}
+
+// Name of the rule
+func (r *PercentChange) Name() string {
+	return fmt.Sprintf("%s moves %g%% in %s", strings.ToUpper(r.Asset), r.Percent, r.Window)
+}
+
+// Needs prices
+func (r *PercentChange) Needs() (Source, string) {
+	return Prices, ""
+}
+
+// Check will compare the price with the price a window ago, nothing is raised until the
+// daemon has seen a full window of prices
+func (r *PercentChange) Check(state *State) []*Alert {
+	code := strings.ToUpper(r.Asset)
+	price, ok := state.Prices[code]
+	if !ok {
+		return nil
+	}
+	then, ok := state.PriceAt(code, state.Time.Add(-r.Window))
+	if !ok || then == 0 {
+		return nil
+	}
+
+	change := (price - then) / then * 100
+	if math.Abs(change) < r.Percent {
+		return nil
+	}
+
+	return []*Alert{{
+		Subject: fmt.Sprintf("%s changed %+.2f%% in %s", code, change, r.Window),
+		Message: fmt.Sprintf("%s moved from %g to %g", code, then, price),
+	}}
+}
+
+// BalanceBelow raises an alert while an asset's available balance is below a threshold
+type BalanceBelow struct {
+	Asset     string
+	Threshold float64
+}
+
+// Name of the rule
+func (r *BalanceBelow) Name() string {
+	return fmt.Sprintf("%s balance below %g", strings.ToUpper(r.Asset), r.Threshold)
+}
+
+// Needs balances
+func (r *BalanceBelow) Needs() (Source, string) {
+	return Balances, ""
+}
+
+// Check will raise an alert if the balance is below the threshold, an asset with no balance
+// has a balance of zero
+func (r *BalanceBelow) Check(state *State) []*Alert {
+	code := strings.ToUpper(r.Asset)
+	balance := state.Balances[code]
+	if balance >= r.Threshold {
+		return nil
+	}
+
+	return []*Alert{{
+		Subject: fmt.Sprintf("%s balance is below %g", code, r.Threshold),
+		Message: fmt.Sprintf("available %s balance is %g", code, balance),
+	}}
+}
+
+// OrderFilled raises an alert for every order of an asset that completed since the last check
+type OrderFilled struct {
+	Asset string
+}
+
+// Name of the rule
+func (r *OrderFilled) Name() string {
+	return strings.ToUpper(r.Asset) + " order filled"
+}
+
+// Needs the asset's orders
+func (r *OrderFilled) Needs() (Source, string) {
+	return Orders, r.Asset
+}
+
+// Check will raise an alert for each completed order that wasn't completed at the last check.
+// Orders that were already completed at the first check aren't alerted
+func (r *OrderFilled) Check(state *State) []*Alert {
+	if state.Previous == nil {
+		return nil
+	}
+	code := strings.ToUpper(r.Asset)
+
+	completed := make(map[int]bool)
+	for _, order := range state.Previous.Orders[code] {
+		if order.Status == goswyftx.OrderStatusCompleted {
+			completed[order.ID] = true
+		}
+	}
+
+	var alerts []*Alert
+	for _, order := range state.Orders[code] {
+		if order.Status != goswyftx.OrderStatusCompleted || completed[order.ID] {
+			continue
+		}
+
+		side := "sell"
+		if goswyftx.IsBuyOrder(order.Type) {
+			side = "buy"
+		}
+		alerts = append(alerts, &Alert{
+			Key:     "order-filled/" + strconv.Itoa(order.ID),
+			Subject: fmt.Sprintf("%s %s order %d filled", code, side, order.ID),
+			Message: fmt.Sprintf("%s order %d for %s/%s filled", side, order.ID,
+				order.PrimaryAsset, order.SecondaryAsset),
+		})
+	}
+
+	return alerts
+}
+
+// WithdrawalCompleted raises an alert for every withdrawal of an asset that completed since
+// the last check
+type WithdrawalCompleted struct {
+	Asset string
+}
+
+// Name of the rule
+func (r *WithdrawalCompleted) Name() string {
+	return strings.ToUpper(r.Asset) + " withdrawal completed"
+}
+
+// Needs the asset's withdrawals
+func (r *WithdrawalCompleted) Needs() (Source, string) {
+	return Withdrawals, r.Asset
+}
+
+// Check will raise an alert for each completed withdrawal that wasn't completed at the last
+// check. Withdrawals that were already completed at the first check aren't alerted
+func (r *WithdrawalCompleted) Check(state *State) []*Alert {
+	if state.Previous == nil {
+		return nil
+	}
+	code := strings.ToUpper(r.Asset)
+
+	completed := make(map[int]bool)
+	for _, withdrawal := range state.Previous.Withdrawals[code] {
+		if goswyftx.ClassifyHistoryStatus(withdrawal.Status) == goswyftx.HistoryStatusCompleted {
+			completed[withdrawal.ID] = true
+		}
+	}
+
+	var alerts []*Alert
+	for _, withdrawal := range state.Withdrawals[code] {
+		if goswyftx.ClassifyHistoryStatus(withdrawal.Status) != goswyftx.HistoryStatusCompleted ||
+			completed[withdrawal.ID] {
+			continue
+		}
+
+		alerts = append(alerts, &Alert{
+			Key:     "withdrawal-completed/" + strconv.Itoa(withdrawal.ID),
+			Subject: fmt.Sprintf("%s withdrawal %d completed", code, withdrawal.ID),
+			Message: fmt.Sprintf("withdrawal of %s %s completed", withdrawal.Quantity, code),
+		})
+	}
+
+	return alerts
+}
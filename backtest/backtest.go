@@ -0,0 +1,140 @@
+// Package backtest replays historical chart data through a trading strategy and simulates
+// how its orders would have been filled on swyftx
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+var (
+	errNoBars = errors.New("no bars to backtest")
+	errPair   = errors.New("order asset pair does not match the backtest pair")
+)
+
+// Trader can place and cancel orders. It is satisfied by *goswyftx.OrderService so a strategy
+// written against a backtest can be run live without any changes
+type Trader interface {
+	Place(order *goswyftx.OrderPlace, opts ...goswyftx.PlaceOption) (int, error)
+	Cancel(orderID int) error
+}
+
+var _ Trader = (*goswyftx.OrderService)(nil)
+
+// Strategy decides what orders to place as each new bar closes
+type Strategy interface {
+	Next(trader Trader, bar *goswyftx.OCHLVT) error
+}
+
+// StrategyFunc lets an ordinary function be used as a Strategy
+type StrategyFunc func(trader Trader, bar *goswyftx.OCHLVT) error
+
+// Next will call f
+func (f StrategyFunc) Next(trader Trader, bar *goswyftx.OCHLVT) error {
+	return f(trader, bar)
+}
+
+// Config of a backtest
+type Config struct {
+	// Primary is the asset the account is funded in e.g. AUD
+	Primary string
+	// Secondary is the asset being traded e.g. BTC
+	Secondary string
+	// Cash is the starting balance of the primary asset
+	Cash float64
+	// Spread is the fraction between the buy and sell price e.g. 0.005 for 0.5%, half of it
+	// is paid on each fill
+	Spread float64
+	// Fee is the fraction of each fill's value charged by the exchange e.g. 0.006 for 0.6%
+	Fee float64
+	// Costs replaces Spread and Fee with a cost model's spread and fee tier, it is optional
+	Costs *goswyftx.CostModel
+	// Asset holds the minimum order and increment rules for the secondary asset, it is
+	// optional
+	Asset *goswyftx.MarketAsset
+	// PeriodsPerYear is the number of bars in a year used to annualise the Sharpe ratio, if
+	// zero it is worked out from the spacing of the first two bars
+	PeriodsPerYear float64
+}
+
+// Trade is a simulated fill
+type Trade struct {
+	Time     time.Time
+	OrderID  int
+	Buy      bool
+	Quantity float64
+	Price    float64
+	Fee      float64
+	// Profit realised by a sell against the average cost of the holding, zero for buys
+	Profit float64
+}
+
+// EquityPoint is the value of the account at the close of a bar
+type EquityPoint struct {
+	Time   time.Time
+	Equity float64
+}
+
+// Result of a backtest
+type Result struct {
+	Equity []EquityPoint
+	Trades []*Trade
+	// MaxDrawdown is the largest fall from a peak in equity as a fraction of that peak
+	MaxDrawdown float64
+	// Sharpe is the annualised Sharpe ratio of the per bar returns with a zero risk free rate
+	Sharpe float64
+	// WinRate is the fraction of sells that realised a profit
+	WinRate float64
+	// Cash and Holding left at the end of the backtest
+	Cash    float64
+	Holding float64
+}
+
+// Run will replay bars through a strategy, orders placed during a bar are filled at that bar's
+// close and open limit orders are checked against each following bar
+func Run(cfg Config, bars []*goswyftx.OCHLVT, strategy Strategy) (*Result, error) {
+	if len(bars) == 0 {
+		return nil, errNoBars
+	}
+
+	broker, err := NewBroker(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var equity []EquityPoint
+	for _, bar := range bars {
+		if err = broker.Update(bar); err != nil {
+			return nil, fmt.Errorf("could not update broker: %s", err.Error())
+		}
+
+		if err = strategy.Next(broker, bar); err != nil {
+			return nil, fmt.Errorf("strategy failed at %s: %s", bar.Time.Format(time.RFC3339),
+				err.Error())
+		}
+
+		equity = append(equity, EquityPoint{bar.Time.Time, broker.Equity()})
+	}
+
+	periods := cfg.PeriodsPerYear
+	if periods == 0 && len(bars) > 1 {
+		if spacing := bars[1].Time.Sub(bars[0].Time.Time); spacing > 0 {
+			periods = float64(365*24*time.Hour) / float64(spacing)
+		}
+	}
+
+	result := &Result{
+		Equity:      equity,
+		Trades:      broker.Trades(),
+		MaxDrawdown: maxDrawdown(equity),
+		Sharpe:      sharpe(equity, periods),
+		WinRate:     winRate(broker.Trades()),
+		Cash:        broker.Cash(),
+		Holding:     broker.Holding(),
+	}
+
+	return result, nil
+}
@@ -0,0 +1,251 @@
+package backtest_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+	"github.com/joshturge/goswyftx/backtest"
+)
+
+func TestRun(t *testing.T) {
+	start := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+	closes := []string{"100", "110", "120", "90"}
+
+	var bars []*goswyftx.OCHLVT
+	for i, c := range closes {
+		bars = append(bars, &goswyftx.OCHLVT{
+			Time:  goswyftx.SwyftxTime{Time: start.Add(time.Duration(i) * time.Hour)},
+			Open:  c,
+			High:  c,
+			Low:   c,
+			Close: c,
+		})
+	}
+
+	// buy on the first bar and sell on the third
+	strategy := backtest.StrategyFunc(func(trader backtest.Trader, bar *goswyftx.OCHLVT) error {
+		order := &goswyftx.OrderPlace{Primary: "AUD", Secondary: "BTC", AssetQuantity: "BTC",
+			Quantity: 1}
+		switch bar.Close {
+		case "100":
+			order.OrderType = goswyftx.OrderTypeMarketBuy
+		case "120":
+			order.OrderType = goswyftx.OrderTypeMarketSell
+		default:
+			return nil
+		}
+		_, err := trader.Place(order)
+		return err
+	})
+
+	result, err := backtest.Run(backtest.Config{
+		Primary:   "AUD",
+		Secondary: "BTC",
+		Cash:      1000,
+		Fee:       0.01,
+		Asset:     &goswyftx.MarketAsset{MinimumOrder: "0.5"},
+	}, bars, strategy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Trades) != 2 {
+		t.Fatalf("expected 2 trades, got %d", len(result.Trades))
+	}
+
+	// 1000 - 100 - 1 (fee) + 120 - 1.2 (fee)
+	if want := 1017.8; math.Abs(result.Cash-want) > 1e-9 {
+		t.Errorf("expected cash of %f, got %f", want, result.Cash)
+	}
+	if result.WinRate != 1 {
+		t.Errorf("expected a win rate of 1, got %f", result.WinRate)
+	}
+	if len(result.Equity) != len(bars) {
+		t.Errorf("expected %d equity points, got %d", len(bars), len(result.Equity))
+	}
+}
+
+func TestMinimumOrder(t *testing.T) {
+	broker, err := backtest.NewBroker(backtest.Config{
+		Primary:   "AUD",
+		Secondary: "BTC",
+		Cash:      1000,
+		Asset:     &goswyftx.MarketAsset{MinimumOrder: "0.5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = broker.Update(&goswyftx.OCHLVT{Open: "1", High: "1", Low: "1", Close: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = broker.Place(&goswyftx.OrderPlace{Primary: "AUD", Secondary: "BTC",
+		AssetQuantity: "BTC", Quantity: 0.1, OrderType: goswyftx.OrderTypeMarketBuy})
+	if err != backtest.ErrMinimumOrder {
+		t.Errorf("expected minimum order error, got %v", err)
+	}
+}
+
+func TestSaveAndLoadBars(t *testing.T) {
+	start := time.Date(2021, time.March, 4, 5, 6, 7, 500000000, time.UTC)
+	bars := []*goswyftx.OCHLVT{
+		{Time: goswyftx.SwyftxTime{Time: start}, Open: "1", High: "2", Low: "0.5", Close: "1.5",
+			Volume: 10},
+		{Time: goswyftx.SwyftxTime{Time: start.Add(time.Hour)}, Close: "1.75"},
+	}
+
+	var buf bytes.Buffer
+	if err := backtest.SaveBars(&buf, bars); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := backtest.LoadBars(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded) != len(bars) {
+		t.Fatalf("expected %d bars, got %d", len(bars), len(loaded))
+	}
+	for i, bar := range bars {
+		got := loaded[i]
+		drift := math.Abs(float64(got.Time.Sub(bar.Time.Time)))
+		if drift > float64(time.Microsecond) || got.Open != bar.Open ||
+			got.High != bar.High || got.Low != bar.Low || got.Close != bar.Close ||
+			got.Volume != bar.Volume {
+			t.Errorf("bar %d: expected %+v, got %+v", i, bar, got)
+		}
+	}
+}
+
+func TestRunLiveInvalidPoll(t *testing.T) {
+	err := backtest.RunLive(context.Background(), nil, nil, goswyftx.ChartAsset{}, 0, nil, nil)
+	if err == nil {
+		t.Error("expected a zero poll interval to be rejected")
+	}
+}
+
+func TestBrokerIncrement(t *testing.T) {
+	broker, err := backtest.NewBroker(backtest.Config{
+		Primary:   "AUD",
+		Secondary: "BTC",
+		Cash:      1000,
+		Asset:     &goswyftx.MarketAsset{MinimumOrderIncrement: 0.01},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bar := &goswyftx.OCHLVT{Open: "1000", High: "1000", Low: "1000", Close: "1000"}
+	if err = broker.Update(bar); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = broker.Place(&goswyftx.OrderPlace{Primary: "AUD", Secondary: "BTC",
+		AssetQuantity: "AUD", Quantity: 70, OrderType: goswyftx.OrderTypeMarketBuy}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a float32 increment of 0.01 widened to a float64 would buy 0.0699999998
+	if holding := broker.Holding(); math.Abs(holding-0.07) > 1e-12 {
+		t.Errorf("expected to hold 0.07 BTC, got %.12f", holding)
+	}
+}
+
+func TestBrokerLimitOrders(t *testing.T) {
+	tests := []struct {
+		name      string
+		orderType string
+		trigger   int
+		bar       *goswyftx.OCHLVT
+		filled    bool
+		price     float64
+	}{
+		{name: "limit buy reached by the low", orderType: goswyftx.OrderTypeLimitBuy,
+			trigger: 90, bar: &goswyftx.OCHLVT{High: "105", Low: "85", Close: "100"},
+			filled: true, price: 90},
+		{name: "limit buy above the low", orderType: goswyftx.OrderTypeLimitBuy, trigger: 90,
+			bar: &goswyftx.OCHLVT{High: "105", Low: "95", Close: "100"}},
+		{name: "limit sell reached by the high", orderType: goswyftx.OrderTypeLimitSell,
+			trigger: 110, bar: &goswyftx.OCHLVT{High: "115", Low: "95", Close: "100"},
+			filled: true, price: 110},
+		{name: "limit sell below the high", orderType: goswyftx.OrderTypeLimitSell,
+			trigger: 110, bar: &goswyftx.OCHLVT{High: "105", Low: "95", Close: "100"}},
+		{name: "stop sell reached by the low", orderType: goswyftx.OrderTypeStopLimitSell,
+			trigger: 90, bar: &goswyftx.OCHLVT{High: "100", Low: "80", Close: "85"},
+			filled: true, price: 90},
+		{name: "stop buy reached by the high", orderType: goswyftx.OrderTypeStopLimitBuy,
+			trigger: 110, bar: &goswyftx.OCHLVT{High: "120", Low: "100", Close: "115"},
+			filled: true, price: 110},
+	}
+
+	for _, test := range tests {
+		broker, err := backtest.NewBroker(backtest.Config{Primary: "AUD", Secondary: "BTC",
+			Cash: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// start with 1 BTC so sells have something to fill against
+		if err = broker.Update(&goswyftx.OCHLVT{High: "100", Low: "100", Close: "100"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = broker.Place(&goswyftx.OrderPlace{Primary: "AUD", Secondary: "BTC",
+			AssetQuantity: "BTC", Quantity: 1, OrderType: goswyftx.OrderTypeMarketBuy}); err != nil {
+			t.Fatal(err)
+		}
+
+		id, err := broker.Place(&goswyftx.OrderPlace{Primary: "AUD", Secondary: "BTC",
+			AssetQuantity: "BTC", Quantity: 1, OrderType: test.orderType, Trigger: test.trigger})
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+		if err = broker.Update(test.bar); err != nil {
+			t.Fatal(err)
+		}
+
+		trades := broker.Trades()
+		if !test.filled {
+			if len(trades) != 1 {
+				t.Errorf("%s: expected the order to stay open, got %d trades", test.name,
+					len(trades))
+			}
+			if err = broker.Cancel(id); err != nil {
+				t.Errorf("%s: could not cancel open order: %s", test.name, err.Error())
+			}
+			continue
+		}
+		if len(trades) != 2 {
+			t.Errorf("%s: expected the order to fill, got %d trades", test.name, len(trades))
+			continue
+		}
+		if fill := trades[1]; fill.OrderID != id || fill.Price != test.price ||
+			fill.Buy != goswyftx.IsBuyOrder(test.orderType) {
+			t.Errorf("%s: unexpected fill %+v", test.name, fill)
+		}
+		if err = broker.Cancel(id); err != backtest.ErrUnknownOrder {
+			t.Errorf("%s: expected a filled order to be closed, got %v", test.name, err)
+		}
+	}
+}
+
+func TestBrokerLimitOrderRequiresTrigger(t *testing.T) {
+	broker, err := backtest.NewBroker(backtest.Config{Primary: "AUD", Secondary: "BTC",
+		Cash: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = broker.Update(&goswyftx.OCHLVT{High: "100", Low: "100", Close: "100"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = broker.Place(&goswyftx.OrderPlace{Primary: "AUD", Secondary: "BTC",
+		AssetQuantity: "BTC", Quantity: 1, OrderType: goswyftx.OrderTypeLimitBuy})
+	if err == nil {
+		t.Error("expected a limit order without a trigger to be rejected")
+	}
+}
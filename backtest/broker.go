@@ -0,0 +1,261 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/joshturge/goswyftx"
+)
+
+var (
+	// ErrInsufficientFunds is returned when an order costs more than the simulated balance
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrMinimumOrder is returned when an order is smaller than the asset's minimum order
+	ErrMinimumOrder = errors.New("order is below the minimum order size")
+	// ErrUnknownOrder is returned when cancelling an order that isn't open
+	ErrUnknownOrder = errors.New("order is not open")
+
+	errNoPrice = errors.New("no bar has been seen yet")
+)
+
+type pendingOrder struct {
+	id    int
+	order goswyftx.OrderPlace
+}
+
+// Broker simulates order fills against bars. It implements Trader so it can be used as a
+// paper trading account outside of Run
+type Broker struct {
+	cfg       Config
+	cash      float64
+	holding   float64
+	cost      float64
+	minOrder  float64
+	increment float64
+	bar       *goswyftx.OCHLVT
+	price     float64
+	lastID    int
+	open      []*pendingOrder
+	trades    []*Trade
+}
+
+// NewBroker will create a simulated broker funded with cfg.Cash
+func NewBroker(cfg Config) (*Broker, error) {
+	if cfg.Costs != nil {
+		cfg.Spread, cfg.Fee = cfg.Costs.Spread, cfg.Costs.FeeRate()
+	}
+	b := &Broker{cfg: cfg, cash: cfg.Cash}
+
+	if cfg.Asset != nil {
+		if cfg.Asset.MinimumOrder != "" {
+			var err error
+			if b.minOrder, err = strconv.ParseFloat(cfg.Asset.MinimumOrder, 64); err != nil {
+				return nil, fmt.Errorf("could not parse minimum order: %s", err.Error())
+			}
+		}
+		b.increment = cfg.Asset.Increment()
+	}
+
+	return b, nil
+}
+
+// Update will move the broker onto the next bar and fill any open orders it triggers
+func (b *Broker) Update(bar *goswyftx.OCHLVT) error {
+	price, err := strconv.ParseFloat(bar.Close, 64)
+	if err != nil {
+		return fmt.Errorf("could not parse bar close: %s", err.Error())
+	}
+	high, err := strconv.ParseFloat(bar.High, 64)
+	if err != nil {
+		return fmt.Errorf("could not parse bar high: %s", err.Error())
+	}
+	low, err := strconv.ParseFloat(bar.Low, 64)
+	if err != nil {
+		return fmt.Errorf("could not parse bar low: %s", err.Error())
+	}
+	b.bar, b.price = bar, price
+
+	remaining := b.open[:0]
+	for _, pending := range b.open {
+		trigger := float64(pending.order.Trigger)
+
+		var hit bool
+		switch pending.order.OrderType {
+		case goswyftx.OrderTypeLimitBuy, goswyftx.OrderTypeStopLimitSell:
+			hit = low <= trigger
+		case goswyftx.OrderTypeLimitSell, goswyftx.OrderTypeStopLimitBuy:
+			hit = high >= trigger
+		}
+
+		if !hit {
+			remaining = append(remaining, pending)
+			continue
+		}
+
+		// funds may have changed since the order was placed, an order that can no longer be
+		// filled is dropped the same way the exchange would fail it
+		qty := b.quantity(&pending.order, trigger)
+		if goswyftx.IsBuyOrder(pending.order.OrderType) {
+			_ = b.buy(pending.id, qty, trigger)
+		} else {
+			_ = b.sell(pending.id, qty, trigger)
+		}
+	}
+	b.open = remaining
+
+	return nil
+}
+
+// Place will fill market orders immediately at the current close and hold limit and stop
+// orders until a bar reaches their trigger. Place options are ignored as fills are already
+// simulated at the bar's price
+func (b *Broker) Place(order *goswyftx.OrderPlace, opts ...goswyftx.PlaceOption) (int, error) {
+	if b.bar == nil {
+		return 0, errNoPrice
+	}
+	if order.Primary != b.cfg.Primary || order.Secondary != b.cfg.Secondary {
+		return 0, errPair
+	}
+
+	b.lastID++
+	id := b.lastID
+
+	switch order.OrderType {
+	case goswyftx.OrderTypeMarketBuy:
+		price := b.price * (1 + b.cfg.Spread/2)
+		if err := b.buy(id, b.quantity(order, price), price); err != nil {
+			return 0, err
+		}
+	case goswyftx.OrderTypeMarketSell:
+		price := b.price * (1 - b.cfg.Spread/2)
+		if err := b.sell(id, b.quantity(order, price), price); err != nil {
+			return 0, err
+		}
+	case goswyftx.OrderTypeLimitBuy, goswyftx.OrderTypeLimitSell,
+		goswyftx.OrderTypeStopLimitBuy, goswyftx.OrderTypeStopLimitSell:
+		if order.Trigger <= 0 {
+			return 0, errors.New("limit and stop orders require a trigger")
+		}
+		if err := b.checkMinimum(b.quantity(order, float64(order.Trigger))); err != nil {
+			return 0, err
+		}
+		b.open = append(b.open, &pendingOrder{id, *order})
+	default:
+		return 0, fmt.Errorf("unknown order type: %q", order.OrderType)
+	}
+
+	return id, nil
+}
+
+// Cancel will cancel an open limit or stop order
+func (b *Broker) Cancel(orderID int) error {
+	for i, pending := range b.open {
+		if pending.id == orderID {
+			b.open = append(b.open[:i], b.open[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrUnknownOrder
+}
+
+// Estimate will estimate the cost of an order at the current close using the broker's spread
+// and fee, plus any network fee in the cost model
+func (b *Broker) Estimate(order *goswyftx.OrderPlace) (*goswyftx.CostEstimate, error) {
+	if b.bar == nil {
+		return nil, errNoPrice
+	}
+
+	model := goswyftx.CostModel{
+		Spread:   b.cfg.Spread,
+		FeeTiers: []goswyftx.FeeTier{{Rate: b.cfg.Fee}},
+	}
+	if b.cfg.Costs != nil {
+		model.NetworkFee = b.cfg.Costs.NetworkFee
+	}
+
+	return model.Estimate(order, b.price)
+}
+
+// Equity is the value of the cash and holding at the current close
+func (b *Broker) Equity() float64 {
+	return b.cash + b.holding*b.price
+}
+
+// Cash is the simulated balance of the primary asset
+func (b *Broker) Cash() float64 {
+	return b.cash
+}
+
+// Holding is the simulated balance of the secondary asset
+func (b *Broker) Holding() float64 {
+	return b.holding
+}
+
+// Trades are all the fills so far
+func (b *Broker) Trades() []*Trade {
+	return b.trades
+}
+
+// quantity of the secondary asset an order is for, rounded down to the asset increment
+func (b *Broker) quantity(order *goswyftx.OrderPlace, price float64) float64 {
+	qty := float64(order.Quantity)
+	if order.AssetQuantity == b.cfg.Primary && price > 0 {
+		qty /= price
+	}
+	if b.increment > 0 {
+		qty = math.Floor(qty/b.increment+1e-9) * b.increment
+	}
+
+	return qty
+}
+
+func (b *Broker) checkMinimum(qty float64) error {
+	if qty <= 0 || qty < b.minOrder {
+		return ErrMinimumOrder
+	}
+
+	return nil
+}
+
+func (b *Broker) buy(id int, qty, price float64) error {
+	if err := b.checkMinimum(qty); err != nil {
+		return err
+	}
+
+	value := qty * price
+	fee := value * b.cfg.Fee
+	if value+fee > b.cash {
+		return ErrInsufficientFunds
+	}
+
+	b.cash -= value + fee
+	b.holding += qty
+	b.cost += value + fee
+	b.trades = append(b.trades, &Trade{b.bar.Time.Time, id, true, qty, price, fee, 0})
+
+	return nil
+}
+
+func (b *Broker) sell(id int, qty, price float64) error {
+	if err := b.checkMinimum(qty); err != nil {
+		return err
+	}
+	if qty > b.holding {
+		return ErrInsufficientFunds
+	}
+
+	value := qty * price
+	fee := value * b.cfg.Fee
+	cost := b.cost * qty / b.holding
+
+	b.cash += value - fee
+	b.holding -= qty
+	b.cost -= cost
+	b.trades = append(b.trades, &Trade{b.bar.Time.Time, id, false, qty, price, fee,
+		value - fee - cost})
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+// savedBar is a bar with its time in seconds since the unix epoch. The time is only as
+// precise as the float32 swyftx sends it as, saving it doesn't add any precision
+type savedBar struct {
+	*goswyftx.OCHLVT
+	Time json.Number `json:"time"`
+}
+
+// SaveBars will encode bars as a JSON array that LoadBars can read, so history can be
+// replayed later without hitting the api
+func SaveBars(w io.Writer, bars []*goswyftx.OCHLVT) error {
+	saved := make([]savedBar, len(bars))
+	for i, bar := range bars {
+		seconds := strconv.FormatFloat(float64(bar.Time.UnixNano())/1e9, 'f', -1, 64)
+		saved[i] = savedBar{OCHLVT: bar, Time: json.Number(seconds)}
+	}
+
+	if err := json.NewEncoder(w).Encode(saved); err != nil {
+		return fmt.Errorf("could not encode bars: %s", err.Error())
+	}
+
+	return nil
+}
+
+// LoadBars will decode a JSON array of bars written by SaveBars
+func LoadBars(r io.Reader) ([]*goswyftx.OCHLVT, error) {
+	var saved []savedBar
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return nil, fmt.Errorf("could not decode bars: %s", err.Error())
+	}
+
+	bars := make([]*goswyftx.OCHLVT, len(saved))
+	for i, s := range saved {
+		seconds, err := strconv.ParseFloat(string(s.Time), 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode time of bar %d: %s", i, err.Error())
+		}
+		bar := s.OCHLVT
+		if bar == nil {
+			bar = new(goswyftx.OCHLVT)
+		}
+		sec, frac := math.Modf(seconds)
+		bar.Time = goswyftx.SwyftxTime{Time: time.Unix(int64(sec), int64(frac*1e9))}
+		bars[i] = bar
+	}
+
+	return bars, nil
+}
+
+// RunLive will poll the latest bar for an asset pair and pass each bar to the strategy once it
+// has closed, placing orders through the trader, which is normally client.Order(). A bar has
+// closed when a newer one appears, the strategy gets its values as of the last poll before
+// that so trades in the final poll interval may be missing. A failed poll is passed to onError,
+// which is optional, and the next poll tries again. It returns when ctx is done or the strategy
+// returns an error
+func RunLive(ctx context.Context, chart *goswyftx.ChartService, trader Trader,
+	asset goswyftx.ChartAsset, poll time.Duration, strategy Strategy, onError func(err error)) error {
+	if poll <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	var live liveBars
+	for {
+		bars, err := chart.LatestBar(asset)
+		if err != nil && onError != nil {
+			onError(fmt.Errorf("could not get latest bar: %s", err.Error()))
+		}
+
+		if err == nil && len(bars) > 0 {
+			if closed := live.update(bars[0]); closed != nil {
+				if err = strategy.Next(trader, closed); err != nil {
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// liveBars follows the latest bar as it forms
+type liveBars struct {
+	forming *goswyftx.OCHLVT
+}
+
+// update will replace the forming bar with latest and return the previous bar if latest is
+// newer than it, meaning it has closed. Bars older than the forming bar are ignored
+func (lb *liveBars) update(latest *goswyftx.OCHLVT) *goswyftx.OCHLVT {
+	switch {
+	case lb.forming == nil:
+		lb.forming = latest
+	case latest.Time.After(lb.forming.Time.Time):
+		closed := lb.forming
+		lb.forming = latest
+		return closed
+	case latest.Time.Equal(lb.forming.Time.Time):
+		lb.forming = latest
+	}
+
+	return nil
+}
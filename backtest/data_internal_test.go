@@ -0,0 +1,43 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func TestLiveBars(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	bar := func(offset time.Duration, close string) *goswyftx.OCHLVT {
+		return &goswyftx.OCHLVT{Time: goswyftx.SwyftxTime{Time: start.Add(offset)},
+			Close: close}
+	}
+
+	steps := []struct {
+		latest *goswyftx.OCHLVT
+		closed string
+	}{
+		// the first bar is still forming
+		{bar(0, "100"), ""},
+		{bar(0, "101"), ""},
+		// a newer bar closes the first with its last polled values
+		{bar(time.Minute, "102"), "101"},
+		// an older bar doesn't replace the forming one
+		{bar(0, "99"), ""},
+		{bar(time.Minute, "103"), ""},
+		// polls can skip a bar entirely
+		{bar(3*time.Minute, "104"), "103"},
+	}
+
+	var live liveBars
+	for i, step := range steps {
+		closed := live.update(step.latest)
+		switch {
+		case step.closed == "" && closed != nil:
+			t.Errorf("step %d: expected no closed bar, got %+v", i, closed)
+		case step.closed != "" && (closed == nil || closed.Close != step.closed):
+			t.Errorf("step %d: expected a bar closing at %s, got %+v", i, step.closed, closed)
+		}
+	}
+}
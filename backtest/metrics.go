@@ -0,0 +1,71 @@
+package backtest
+
+import "math"
+
+func maxDrawdown(equity []EquityPoint) float64 {
+	var peak, drawdown float64
+	for _, point := range equity {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		if peak > 0 {
+			if dd := (peak - point.Equity) / peak; dd > drawdown {
+				drawdown = dd
+			}
+		}
+	}
+
+	return drawdown
+}
+
+func sharpe(equity []EquityPoint, periodsPerYear float64) float64 {
+	if len(equity) < 2 {
+		return 0
+	}
+
+	returns := make([]float64, 0, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1].Equity == 0 {
+			continue
+		}
+		returns = append(returns, equity[i].Equity/equity[i-1].Equity-1)
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(returns)-1))
+	if stdDev == 0 {
+		return 0
+	}
+
+	return mean / stdDev * math.Sqrt(periodsPerYear)
+}
+
+func winRate(trades []*Trade) float64 {
+	var sells, wins int
+	for _, trade := range trades {
+		if trade.Buy {
+			continue
+		}
+		sells++
+		if trade.Profit > 0 {
+			wins++
+		}
+	}
+	if sells == 0 {
+		return 0
+	}
+
+	return float64(wins) / float64(sells)
+}
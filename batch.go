@@ -0,0 +1,151 @@
+package goswyftx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultBatchConcurrency is the number of requests made at once when a batch operation isn't
+// given a concurrency
+const defaultBatchConcurrency = 4
+
+// OrderResult is the outcome of placing or cancelling a single order in a batch
+type OrderResult struct {
+	// Order that was placed, nil when cancelling
+	Order   *OrderPlace
+	OrderID int
+	Err     error
+}
+
+// BatchError is returned when some operations in a batch failed
+type BatchError struct {
+	Failed []*OrderResult
+}
+
+func (e *BatchError) Error() string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(len(e.Failed)))
+	b.WriteString(" orders failed")
+	for i, result := range e.Failed {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		if result.OrderID != 0 {
+			b.WriteString(buildString("order ", strconv.Itoa(result.OrderID), ": "))
+		}
+		b.WriteString(result.Err.Error())
+	}
+
+	return b.String()
+}
+
+// PlaceBatch will place orders with at most concurrency requests in flight, a concurrency of
+// zero or less uses a default of 4. A result is returned for every order in the same order
+// they were given, orders not placed before ctx is done fail with the context's error
+func (os *OrderService) PlaceBatch(ctx context.Context, orders []*OrderPlace,
+	concurrency int) []*OrderResult {
+	orderService := os.client.WithContext(ctx).Order()
+
+	results := make([]*OrderResult, len(orders))
+	runConcurrently(len(orders), concurrency, func(i int) {
+		result := &OrderResult{Order: orders[i]}
+		if result.Err = ctx.Err(); result.Err == nil {
+			result.OrderID, result.Err = orderService.Place(orders[i])
+		}
+		results[i] = result
+	})
+
+	return results
+}
+
+// CancelAll will cancel every open or pending order matching filter, cancelling orders as soon
+// as they are found with at most concurrency requests in flight, a concurrency of zero or less
+// uses a default of 4. If the filter has no statuses it matches open and pending orders. If the
+// filter has no secondary asset the orders of every asset in the registry are searched, so an
+// order is found whichever of its assets it is listed under. The IDs of cancelled orders are
+// returned along with a *BatchError if any cancellation failed
+func (os *OrderService) CancelAll(ctx context.Context, filter OrderFilter,
+	concurrency int) ([]int, error) {
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{OrderStatusOpen, OrderStatusPending}
+	}
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	client := os.client.WithContext(ctx)
+	orderService := client.Order()
+
+	it := os.Iterate(ctx, filter)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		cancelled []int
+		failed    []*OrderResult
+		ids       = make(chan int)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				err := orderService.Cancel(id)
+
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, &OrderResult{OrderID: id, Err: err})
+				} else {
+					cancelled = append(cancelled, id)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// an order is only cancelled once even if it is found under both of its assets
+	seen := make(map[int]bool)
+	for it.Next() {
+		if id := it.Order().ID; !seen[id] {
+			seen[id] = true
+			ids <- id
+		}
+	}
+	close(ids)
+	wg.Wait()
+
+	if err := it.Err(); err != nil {
+		return cancelled, err
+	}
+	if len(failed) > 0 {
+		return cancelled, &BatchError{failed}
+	}
+
+	return cancelled, nil
+}
+
+// runConcurrently will call fn for every index from 0 to n with at most concurrency calls
+// running at once
+func runConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,186 @@
+package goswyftx
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidBSB is returned when a BSB or bank account number isn't in a valid format
+var ErrInvalidBSB = errors.New("invalid BSB")
+
+// BSBStatusCode is the result of verifying a BSB
+type BSBStatusCode string
+
+// BSB statuses, other statuses returned by swyftx are kept as they are in lower case
+const (
+	BSBStatusValid   BSBStatusCode = "valid"
+	BSBStatusInvalid BSBStatusCode = "invalid"
+	BSBStatusClosed  BSBStatusCode = "closed"
+)
+
+// UnmarshalJSON will lower case the status so it can be compared with the constants
+func (s *BSBStatusCode) UnmarshalJSON(data []byte) error {
+	var status string
+	if err := json.Unmarshal(data, &status); err != nil {
+		return err
+	}
+	*s = BSBStatusCode(strings.ToLower(strings.TrimSpace(status)))
+
+	return nil
+}
+
+// BSBBranch is a branch in the BSB directory
+type BSBBranch struct {
+	// BSB in the form 123-456
+	BSB string
+	// BankCode is the institution's mnemonic e.g. CBA
+	BankCode string
+	Name     string
+	Address  string
+	Suburb   string
+	State    string
+	PostCode string
+	// Payments are the payment systems the branch takes, P paper, E electronic and H high
+	// value
+	Payments string
+}
+
+// ParseBSB will check a BSB is six digits, optionally split in half by a hyphen or space, and
+// return it in the form 123-456
+func ParseBSB(bsb string) (string, error) {
+	digits := make([]byte, 0, 6)
+	for i := 0; i < len(bsb); i++ {
+		switch c := bsb[i]; {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case (c == '-' || c == ' ') && len(digits) == 3:
+		default:
+			return "", fmt.Errorf("%w: %q must be six digits", ErrInvalidBSB, bsb)
+		}
+	}
+	if len(digits) != 6 {
+		return "", fmt.Errorf("%w: %q must be six digits", ErrInvalidBSB, bsb)
+	}
+	if string(digits[:2]) == "00" {
+		return "", fmt.Errorf("%w: %q has no financial institution", ErrInvalidBSB, bsb)
+	}
+
+	return buildString(string(digits[:3]), "-", string(digits[3:])), nil
+}
+
+// ValidateBankAccount will check the format of a BSB and an Australian account number, which
+// is five to nine digits
+func ValidateBankAccount(bsb, account string) error {
+	if _, err := ParseBSB(bsb); err != nil {
+		return err
+	}
+
+	account = strings.NewReplacer(" ", "", "-", "").Replace(account)
+	if len(account) < 5 || len(account) > 9 {
+		return fmt.Errorf("%w: account number must be five to nine digits", ErrInvalidBSB)
+	}
+	for i := 0; i < len(account); i++ {
+		if account[i] < '0' || account[i] > '9' {
+			return fmt.Errorf("%w: account number must be five to nine digits", ErrInvalidBSB)
+		}
+	}
+
+	return nil
+}
+
+// BSBDirectory resolves BSBs to their bank and branch without a network request
+type BSBDirectory struct {
+	branches map[string]*BSBBranch
+}
+
+// LoadBSBDirectory will read a BSB directory in the APCA CSV format, where each line holds
+// the BSB, bank code, branch name, street address, suburb, state, post code and payment
+// systems. Lines that don't start with a BSB, such as a header, are skipped
+func LoadBSBDirectory(r io.Reader) (*BSBDirectory, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	dir := &BSBDirectory{branches: make(map[string]*BSBBranch)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read BSB directory: %s", err.Error())
+		}
+		if len(record) == 0 {
+			continue
+		}
+
+		bsb, err := ParseBSB(strings.TrimSpace(record[0]))
+		if err != nil {
+			continue
+		}
+
+		fields := make([]string, 8)
+		for i := 1; i < len(record) && i < len(fields); i++ {
+			fields[i] = strings.TrimSpace(record[i])
+		}
+		dir.branches[bsb] = &BSBBranch{
+			BSB:      bsb,
+			BankCode: fields[1],
+			Name:     fields[2],
+			Address:  fields[3],
+			Suburb:   fields[4],
+			State:    fields[5],
+			PostCode: fields[6],
+			Payments: fields[7],
+		}
+	}
+
+	return dir, nil
+}
+
+// Lookup will find the branch of a BSB
+func (d *BSBDirectory) Lookup(bsb string) (*BSBBranch, bool) {
+	bsb, err := ParseBSB(bsb)
+	if err != nil {
+		return nil, false
+	}
+	branch, ok := d.branches[bsb]
+
+	return branch, ok
+}
+
+// Len is the number of branches in the directory
+func (d *BSBDirectory) Len() int {
+	return len(d.branches)
+}
+
+// ResolveBSB will check the format of a BSB and look it up in the directory, falling back to
+// VerifyBSB when the directory doesn't have it. The directory can be nil to always verify
+// with swyftx
+func (as *AddressService) ResolveBSB(dir *BSBDirectory, bsb string) (*BSBStatus, error) {
+	normalised, err := ParseBSB(bsb)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir != nil {
+		if branch, ok := dir.Lookup(normalised); ok {
+			return &BSBStatus{
+				Status:   BSBStatusValid,
+				Address:  branch.Address,
+				BankCode: branch.BankCode,
+				BSB:      branch.BSB,
+				City:     branch.Suburb,
+				PostCode: branch.PostCode,
+				State:    branch.State,
+			}, nil
+		}
+	}
+
+	return as.VerifyBSB(strings.Replace(normalised, "-", "", 1))
+}
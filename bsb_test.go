@@ -0,0 +1,76 @@
+package goswyftx_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func TestParseBSB(t *testing.T) {
+	tests := []struct {
+		bsb  string
+		want string
+	}{
+		{"062-000", "062-000"},
+		{"062000", "062-000"},
+		{"062 000", "062-000"},
+		{"06-2000", ""},
+		{"06200", ""},
+		{"0620001", ""},
+		{"002-000", ""},
+		{"06a-000", ""},
+	}
+
+	for _, test := range tests {
+		got, err := goswyftx.ParseBSB(test.bsb)
+		if test.want == "" {
+			if !errors.Is(err, goswyftx.ErrInvalidBSB) {
+				t.Errorf("%q: expected ErrInvalidBSB, got %v", test.bsb, err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%q: expected %s, got %s (%v)", test.bsb, test.want, got, err)
+		}
+	}
+
+	if err := goswyftx.ValidateBankAccount("062-000", "1234 5678"); err != nil {
+		t.Errorf("expected a valid account, got %v", err)
+	}
+	if err := goswyftx.ValidateBankAccount("062-000", "1234"); err == nil {
+		t.Error("expected a four digit account to be invalid")
+	}
+}
+
+func TestBSBDirectory(t *testing.T) {
+	const apca = `"BSB","Mnemonic","Name","Address","Suburb","State","Postcode","Payments"
+"062-000","CBA","48 Martin Place","48 Martin Place","Sydney","NSW","2000","PEH"
+"083004","NAB","Melbourne","500 Bourke Street","Melbourne","VIC","3000","PEH"
+`
+	dir, err := goswyftx.LoadBSBDirectory(strings.NewReader(apca))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir.Len() != 2 {
+		t.Fatalf("expected 2 branches, got %d", dir.Len())
+	}
+
+	branch, ok := dir.Lookup("083 004")
+	if !ok || branch.BankCode != "NAB" || branch.BSB != "083-004" || branch.State != "VIC" {
+		t.Errorf("unexpected branch: %+v", branch)
+	}
+	if _, ok = dir.Lookup("999-999"); ok {
+		t.Error("expected an unknown BSB not to be found")
+	}
+
+	var status goswyftx.BSBStatus
+	if err = json.Unmarshal([]byte(`{"status":"Closed"}`), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != goswyftx.BSBStatusClosed {
+		t.Errorf("expected a closed status, got %q", status.Status)
+	}
+}
@@ -18,11 +18,16 @@ const (
 
 // Client holds the connection to swyftx and the api key and token for authentication
 type Client struct {
-	httpConn  *http.Client
-	apiKey    string
-	token     string
-	userAgent string
-	ctx       context.Context
+	httpConn   *http.Client
+	apiKey     string
+	token      string
+	userAgent  string
+	ctx        context.Context
+	killSwitch *KillSwitch
+	withdrawal *WithdrawalPolicy
+	// assets is shared by copies of the client so order guards don't fetch every asset
+	// each time an order is placed
+	assets *registryCache
 }
 
 type service struct {
@@ -35,7 +40,8 @@ func NewClientWithContext(ctx context.Context, apiKey, token string) (*Client, e
 	client := &Client{
 		token:  token,
 		apiKey: apiKey,
-		ctx:    ctx}
+		ctx:    ctx,
+		assets: new(registryCache)}
 
 	// create http client for API
 	cf := &tls.Config{Rand: rand.Reader}
@@ -116,6 +122,11 @@ func (c *Client) Do(req *http.Request, v interface{}) (resp *http.Response, err
 		return resp, &errResp.Error
 	}
 
+	// requests like deleting an order have no response to decode
+	if v == nil {
+		return resp, nil
+	}
+
 	if err = decodeJSON(body, v); err != nil {
 		return resp, fmt.Errorf("could not decode response: %s", err.Error())
 	}
@@ -132,6 +143,7 @@ func (c *Client) Request(method, path string, body, v interface{}) error {
 
 	var resp *http.Response
 	resp, err = c.Do(req, v)
+	c.killSwitch.record(err)
 	if err != nil {
 		return fmt.Errorf("could not do request: %s", err.Error())
 	}
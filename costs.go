@@ -0,0 +1,250 @@
+package goswyftx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrCostExceeded is returned by Place when an order guarded by WithMaxCost is estimated to
+// cost more than the allowed fraction of its value
+var ErrCostExceeded = errors.New("order cost exceeds tolerance")
+
+// FeeTier is the exchange fee rate charged once an account has traded at least MinVolume
+type FeeTier struct {
+	MinVolume float64
+	Rate      float64
+}
+
+// DefaultFeeTiers charges DefaultFeeRate regardless of volume
+var DefaultFeeTiers = []FeeTier{{0, DefaultFeeRate}}
+
+// CostModel estimates the cost of trading an asset without making any requests, so it can be
+// used by backtests and paper trading as well as before placing a live order
+type CostModel struct {
+	// Spread is the fraction between the buy and sell price e.g. 0.005 for 0.5%, half of it is
+	// paid on each market order
+	Spread float64
+	// FeeTiers are the exchange fee rates by traded volume, defaults to DefaultFeeTiers
+	FeeTiers []FeeTier
+	// Volume the account has traded, used to pick a fee tier
+	Volume float64
+	// NetworkFee is the mining fee in the asset charged to withdraw it after buying, zero if
+	// it won't be withdrawn
+	NetworkFee float64
+}
+
+// CostEstimate is the expected cost of an order, values are in the order's primary asset and
+// quantities in its secondary asset
+type CostEstimate struct {
+	// Mid price of the secondary asset the estimate was made at
+	Mid float64
+	// Price the order is expected to fill at
+	Price    float64
+	Quantity float64
+	// Value of the order at the mid price
+	Value float64
+	// SpreadCost is the difference between filling at Price rather than Mid
+	SpreadCost float64
+	FeeRate    float64
+	Fee        float64
+	// NetworkFee is the withdrawal fee in the secondary asset and NetworkFeeValue its value
+	NetworkFee      float64
+	NetworkFeeValue float64
+	// Total cost of the order in the primary asset and TotalAsset the same in the secondary
+	Total      float64
+	TotalAsset float64
+}
+
+// Fraction is the total cost as a fraction of the order's value
+func (e *CostEstimate) Fraction() float64 {
+	if e.Value == 0 {
+		return 0
+	}
+
+	return e.Total / e.Value
+}
+
+// FeeRate will return the fee rate of the highest tier the model's volume reaches
+func (m *CostModel) FeeRate() float64 {
+	tiers := m.FeeTiers
+	if len(tiers) == 0 {
+		tiers = DefaultFeeTiers
+	}
+	sorted := make([]FeeTier, len(tiers))
+	copy(sorted, tiers)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinVolume < sorted[j].MinVolume })
+
+	rate := sorted[0].Rate
+	for _, tier := range sorted {
+		if m.Volume >= tier.MinVolume {
+			rate = tier.Rate
+		}
+	}
+
+	return rate
+}
+
+// FillPrice is the price a market order is expected to fill at given the mid price
+func (m *CostModel) FillPrice(mid float64, buy bool) float64 {
+	if buy {
+		return mid * (1 + m.Spread/2)
+	}
+
+	return mid * (1 - m.Spread/2)
+}
+
+// Estimate will work out the cost of an order given the mid price of its secondary asset in
+// its primary asset. Market orders pay half the spread, limit and stop orders fill at their
+// trigger. The network fee is only charged on buys
+func (m *CostModel) Estimate(order *OrderPlace, mid float64) (*CostEstimate, error) {
+	if mid <= 0 {
+		return nil, fmt.Errorf("invalid mid price: %g", mid)
+	}
+
+	buy := IsBuyOrder(order.OrderType)
+	estimate := &CostEstimate{Mid: mid, FeeRate: m.FeeRate()}
+	switch order.OrderType {
+	case OrderTypeMarketBuy, OrderTypeMarketSell:
+		estimate.Price = m.FillPrice(mid, buy)
+	case OrderTypeLimitBuy, OrderTypeLimitSell, OrderTypeStopLimitBuy, OrderTypeStopLimitSell:
+		if order.Trigger <= 0 {
+			return nil, errors.New("limit and stop orders require a trigger")
+		}
+		estimate.Price = float64(order.Trigger)
+	default:
+		return nil, fmt.Errorf("unknown order type: %q", order.OrderType)
+	}
+
+	estimate.Quantity = float64(order.Quantity)
+	if strings.EqualFold(order.AssetQuantity, order.Primary) {
+		estimate.Quantity /= estimate.Price
+	}
+	estimate.Value = estimate.Quantity * mid
+	estimate.SpreadCost = math.Abs(estimate.Price-mid) * estimate.Quantity
+	estimate.Fee = estimate.Quantity * estimate.Price * estimate.FeeRate
+	if buy {
+		estimate.NetworkFee = m.NetworkFee
+		estimate.NetworkFeeValue = m.NetworkFee * mid
+	}
+	estimate.Total = estimate.SpreadCost + estimate.Fee + estimate.NetworkFeeValue
+	estimate.TotalAsset = estimate.Total / mid
+
+	return estimate, nil
+}
+
+// CostService builds cost models from live market data
+type CostService struct {
+	service
+	tiers []FeeTier
+}
+
+// Costs will return a cost service that can estimate the cost of orders, fees are charged at
+// DefaultFeeTiers
+func (c *Client) Costs() *CostService {
+	return &CostService{service{c}, DefaultFeeTiers}
+}
+
+// WithFeeTiers will use different fee tiers, e.g. for an account with discounted fees
+func (cs *CostService) WithFeeTiers(tiers []FeeTier) *CostService {
+	return &CostService{cs.service, tiers}
+}
+
+// Model will build a cost model for an asset from its live buy and sell prices and the
+// account's traded volume. If withdraw is true the asset's mining fee is included
+func (cs *CostService) Model(assetCode string, withdraw bool) (*CostModel, error) {
+	info, err := cs.client.Market().BasicInfo(assetCode)
+	if err != nil {
+		return nil, fmt.Errorf("could not get basic info for %s: %s", assetCode, err.Error())
+	}
+	spread, err := infoSpread(info)
+	if err != nil {
+		return nil, fmt.Errorf("could not get spread of %s: %s", assetCode, err.Error())
+	}
+
+	stats, err := cs.client.Account().Statistics()
+	if err != nil {
+		return nil, fmt.Errorf("could not get account statistics: %s", err.Error())
+	}
+
+	model := &CostModel{Spread: spread, FeeTiers: cs.tiers, Volume: float64(stats.Traded)}
+	if withdraw {
+		registry, err := cs.client.Market().Registry()
+		if err != nil {
+			return nil, fmt.Errorf("could not get assets: %s", err.Error())
+		}
+		asset, ok := registry.ByCode(assetCode)
+		if !ok {
+			return nil, fmt.Errorf("unknown asset: %s", assetCode)
+		}
+		model.NetworkFee = float64(asset.MiningFee)
+	}
+
+	return model, nil
+}
+
+// Estimate will estimate the cost of an order at the live mid price, including the fee to
+// withdraw the secondary asset afterwards if withdraw is true
+func (cs *CostService) Estimate(order *OrderPlace, withdraw bool) (*CostEstimate, error) {
+	model, err := cs.Model(order.Secondary, withdraw)
+	if err != nil {
+		return nil, err
+	}
+
+	mid, err := cs.client.Order().midPrice(order.Primary, order.Secondary)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.Estimate(order, mid)
+}
+
+// infoSpread is the spread as a fraction of the mid price. It is worked out from the buy and
+// sell prices, falling back to the spread field which is a percentage
+func infoSpread(info *MarketBasicInfo) (float64, error) {
+	buy, err := parseFloat(info.Buy)
+	if err != nil {
+		return 0, err
+	}
+	sell, err := parseFloat(info.Sell)
+	if err != nil {
+		return 0, err
+	}
+	if mid := (buy + sell) / 2; buy > 0 && sell > 0 {
+		return math.Abs(buy-sell) / mid, nil
+	}
+
+	spread, err := parseFloat(info.Spread)
+	if err != nil {
+		return 0, err
+	}
+
+	return spread / 100, nil
+}
+
+// WithMaxCost will estimate the cost of an order before placing it and refuse to place it
+// with ErrCostExceeded if the spread and fee come to more than tolerance of its value, a
+// fraction such as 0.01 for 1%
+func WithMaxCost(tolerance float64) PlaceOption {
+	return func(opts *placeOptions) {
+		opts.maxCost = tolerance
+	}
+}
+
+func (os *OrderService) guardCost(order *OrderPlace, opts *placeOptions) error {
+	if opts.maxCost <= 0 {
+		return nil
+	}
+
+	estimate, err := os.client.Costs().Estimate(order, false)
+	if err != nil {
+		return fmt.Errorf("could not estimate order cost: %s", err.Error())
+	}
+	if fraction := estimate.Fraction(); fraction > opts.maxCost {
+		return fmt.Errorf("%w: %.2f%% of the order value", ErrCostExceeded, fraction*100)
+	}
+
+	return nil
+}
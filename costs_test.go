@@ -0,0 +1,67 @@
+package goswyftx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func TestCostModelEstimate(t *testing.T) {
+	model := &goswyftx.CostModel{
+		Spread: 0.02,
+		FeeTiers: []goswyftx.FeeTier{
+			{MinVolume: 100000, Rate: 0.004},
+			{MinVolume: 0, Rate: 0.006},
+		},
+		Volume:     5000,
+		NetworkFee: 0.01,
+	}
+	if rate := model.FeeRate(); rate != 0.006 {
+		t.Errorf("expected a fee rate of 0.006, got %f", rate)
+	}
+
+	estimate, err := model.Estimate(&goswyftx.OrderPlace{
+		Primary:       "AUD",
+		Secondary:     "BTC",
+		Quantity:      1,
+		AssetQuantity: "BTC",
+		OrderType:     goswyftx.OrderTypeMarketBuy,
+	}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		name          string
+		got, expected float64
+	}{
+		{"price", estimate.Price, 101},
+		{"spread cost", estimate.SpreadCost, 1},
+		{"fee", estimate.Fee, 0.606},
+		{"network fee value", estimate.NetworkFeeValue, 1},
+		{"total", estimate.Total, 2.606},
+		{"total asset", estimate.TotalAsset, 0.02606},
+	}
+	for _, e := range expected {
+		if math.Abs(e.got-e.expected) > 1e-9 {
+			t.Errorf("expected %s of %f, got %f", e.name, e.expected, e.got)
+		}
+	}
+
+	model.Volume = 100000
+	estimate, err = model.Estimate(&goswyftx.OrderPlace{
+		Primary:       "AUD",
+		Secondary:     "BTC",
+		Quantity:      99,
+		AssetQuantity: "AUD",
+		OrderType:     goswyftx.OrderTypeMarketSell,
+	}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(estimate.Quantity-1) > 1e-9 || estimate.NetworkFee != 0 ||
+		math.Abs(estimate.Fee-0.396) > 1e-9 {
+		t.Errorf("unexpected sell estimate: %+v", estimate)
+	}
+}
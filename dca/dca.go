@@ -0,0 +1,264 @@
+// Package dca runs recurring buys of assets on a schedule, journaling every run so a restart
+// never buys twice for the same scheduled time
+package dca
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+// MissedPolicy decides what happens to runs that were missed while the scheduler wasn't
+// running
+type MissedPolicy int
+
+// Missed run policies
+const (
+	// SkipMissed only runs a scheduled time if it came due while the scheduler was running or
+	// is within the grace period, anything older is journaled as skipped
+	SkipMissed MissedPolicy = iota
+	// CatchUpOnce runs the most recent missed time and skips the rest
+	CatchUpOnce
+	// CatchUpAll runs every missed time
+	CatchUpAll
+)
+
+// defaultGrace is how late a missed run can be before SkipMissed skips it
+const defaultGrace = 5 * time.Minute
+
+// Plan is a recurring buy of an asset
+type Plan struct {
+	// Name identifies the plan in the journal, it must be unique and shouldn't change
+	Name string
+	// Primary is the asset spent e.g. AUD and Secondary is the asset bought e.g. BTC
+	Primary   string
+	Secondary string
+	// Amount of the primary asset spent each run
+	Amount   float64
+	Schedule Schedule
+}
+
+// Config of a scheduler
+type Config struct {
+	Plans       []*Plan
+	JournalPath string
+	Policy      MissedPolicy
+	// Grace is how late a run that was missed while the scheduler wasn't running can be
+	// before SkipMissed skips it, defaults to 5 minutes. Runs that come due between two
+	// checks are never skipped however long the poll interval is
+	Grace time.Duration
+}
+
+// Scheduler runs plans when they are due
+type Scheduler struct {
+	client  *goswyftx.Client
+	cfg     Config
+	journal *journal
+
+	mu sync.Mutex
+	// checked is when due plans were last checked, zero until the first check
+	checked time.Time
+}
+
+// New will create a scheduler and load its journal, creating it if it doesn't exist
+func New(client *goswyftx.Client, cfg Config) (*Scheduler, error) {
+	names := make(map[string]bool)
+	for _, plan := range cfg.Plans {
+		if plan.Name == "" || names[plan.Name] {
+			return nil, fmt.Errorf("plan names must be unique and not empty: %q", plan.Name)
+		}
+		names[plan.Name] = true
+
+		if plan.Amount <= 0 {
+			return nil, fmt.Errorf("plan %s must have a positive amount", plan.Name)
+		}
+		if plan.Schedule == nil {
+			return nil, fmt.Errorf("plan %s must have a schedule", plan.Name)
+		}
+		if interval, ok := plan.Schedule.(Interval); ok && interval.Every <= 0 {
+			return nil, fmt.Errorf("plan %s must have a positive interval", plan.Name)
+		}
+	}
+	if cfg.Grace <= 0 {
+		cfg.Grace = defaultGrace
+	}
+
+	j, err := openJournal(cfg.JournalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scheduler{client: client, cfg: cfg, journal: j}, nil
+}
+
+// Close will close the journal
+func (s *Scheduler) Close() error {
+	return s.journal.close()
+}
+
+// Run will run due plans every poll interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context, poll time.Duration) error {
+	if poll <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunDue(time.Now()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue will run every plan that is due at now and return what was journaled. A failed
+// order is journaled and doesn't stop other plans from running
+func (s *Scheduler) RunDue(now time.Time) ([]*Execution, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var executions []*Execution
+	for _, plan := range s.cfg.Plans {
+		last, ok := s.journal.lastScheduled[plan.Name]
+		if !ok {
+			// a new plan, missed runs are counted from now
+			registered := &Execution{Plan: plan.Name, Scheduled: now, Executed: now,
+				Status: StatusRegistered}
+			if err := s.journal.append(registered); err != nil {
+				return executions, err
+			}
+			continue
+		}
+
+		var due []time.Time
+		for t := plan.Schedule.Next(last); !t.IsZero() && !t.After(now); t = plan.Schedule.Next(t) {
+			if !s.journal.done(plan.Name, t) {
+				due = append(due, t)
+			}
+		}
+
+		for i, scheduled := range due {
+			var (
+				execution *Execution
+				err       error
+			)
+			if s.shouldRun(scheduled, now, i == len(due)-1) {
+				execution, err = s.execute(plan, scheduled)
+			} else {
+				execution = &Execution{Plan: plan.Name, Scheduled: scheduled, Executed: time.Now(),
+					Status: StatusSkipped}
+				err = s.journal.append(execution)
+			}
+			if err != nil {
+				return executions, err
+			}
+			executions = append(executions, execution)
+		}
+	}
+	s.checked = now
+
+	return executions, nil
+}
+
+// shouldRun decides whether a due scheduled time is run or skipped, latest is whether it is
+// the most recent time due for its plan
+func (s *Scheduler) shouldRun(scheduled, now time.Time, latest bool) bool {
+	switch s.cfg.Policy {
+	case CatchUpAll:
+		return true
+	case CatchUpOnce:
+		return latest
+	}
+
+	// a time after the last check came due while the scheduler was running, it was only
+	// delayed by the poll interval rather than missed
+	onTime := !s.checked.IsZero() && scheduled.After(s.checked)
+	return latest && (onTime || now.Sub(scheduled) <= s.cfg.Grace)
+}
+
+func (s *Scheduler) execute(plan *Plan, scheduled time.Time) (*Execution, error) {
+	execution := &Execution{
+		Plan:      plan.Name,
+		Scheduled: scheduled,
+		Executed:  time.Now(),
+		Amount:    plan.Amount,
+	}
+
+	balance, err := s.balance(plan.Primary)
+	if err != nil {
+		execution.Status, execution.Error = StatusFailed, err.Error()
+		return execution, s.journal.append(execution)
+	}
+	if balance < plan.Amount {
+		execution.Status = StatusInsufficientFunds
+		execution.Error = fmt.Sprintf("%s balance of %g is less than %g", plan.Primary, balance,
+			plan.Amount)
+		return execution, s.journal.append(execution)
+	}
+
+	// journal the run before placing the order so a crash can't lead to buying twice
+	execution.Status = StatusPending
+	if err = s.journal.append(execution); err != nil {
+		return nil, err
+	}
+
+	placed := *execution
+	placed.Executed = time.Now()
+	placed.OrderID, err = s.client.Order().Place(&goswyftx.OrderPlace{
+		Primary:       plan.Primary,
+		Secondary:     plan.Secondary,
+		Quantity:      float32(plan.Amount),
+		AssetQuantity: plan.Primary,
+		OrderType:     goswyftx.OrderTypeMarketBuy,
+	})
+	if err != nil {
+		placed.Status, placed.Error = StatusFailed, err.Error()
+	} else {
+		placed.Status = StatusPlaced
+	}
+
+	return &placed, s.journal.append(&placed)
+}
+
+func (s *Scheduler) balance(assetCode string) (float64, error) {
+	registry, err := s.client.Market().Registry()
+	if err != nil {
+		return 0, fmt.Errorf("could not get assets: %s", err.Error())
+	}
+	asset, ok := registry.ByCode(assetCode)
+	if !ok {
+		return 0, errors.New("unknown asset: " + assetCode)
+	}
+
+	balances, err := s.client.Account().Balance()
+	if err != nil {
+		return 0, fmt.Errorf("could not get balances: %s", err.Error())
+	}
+	for _, balance := range balances {
+		if balance.AssetID != asset.ID {
+			continue
+		}
+		if balance.AvailableBalance == "" {
+			return 0, nil
+		}
+		available, err := strconv.ParseFloat(balance.AvailableBalance, 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse %s balance: %s", assetCode, err.Error())
+		}
+		return available, nil
+	}
+
+	return 0, nil
+}
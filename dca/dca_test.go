@@ -0,0 +1,50 @@
+package dca
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShouldRun(t *testing.T) {
+	start := time.Date(2021, time.May, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		policy    MissedPolicy
+		checked   time.Time
+		scheduled time.Time
+		now       time.Time
+		latest    bool
+		expected  bool
+	}{
+		{"within the grace period", SkipMissed, time.Time{}, start, start.Add(time.Minute),
+			true, true},
+		{"missed before starting", SkipMissed, time.Time{}, start, start.Add(time.Hour), true,
+			false},
+		// polling every hour with a 5 minute grace period
+		{"due between polls", SkipMissed, start.Add(-time.Minute), start,
+			start.Add(59 * time.Minute), true, true},
+		{"due before the last poll", SkipMissed, start.Add(time.Minute), start,
+			start.Add(time.Hour), true, false},
+		{"not the latest", SkipMissed, start.Add(-time.Minute), start, start.Add(time.Minute),
+			false, false},
+		{"catch up once", CatchUpOnce, time.Time{}, start, start.Add(24 * time.Hour), true, true},
+		{"catch up once older", CatchUpOnce, time.Time{}, start, start.Add(24 * time.Hour),
+			false, false},
+		{"catch up all", CatchUpAll, time.Time{}, start, start.Add(24 * time.Hour), false, true},
+	}
+
+	for _, test := range tests {
+		s := &Scheduler{cfg: Config{Policy: test.policy, Grace: defaultGrace}, checked: test.checked}
+		if run := s.shouldRun(test.scheduled, test.now, test.latest); run != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, run)
+		}
+	}
+}
+
+func TestRunRejectsPoll(t *testing.T) {
+	s := &Scheduler{}
+	if err := s.Run(context.Background(), 0); err == nil {
+		t.Error("expected an error for a zero poll interval")
+	}
+}
@@ -0,0 +1,138 @@
+package dca
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Status of an execution
+type Status string
+
+// Execution statuses
+const (
+	// StatusRegistered records when a plan was first seen, missed runs are counted from it
+	StatusRegistered Status = "registered"
+	// StatusPending is journaled before an order is placed
+	StatusPending Status = "pending"
+	StatusPlaced  Status = "placed"
+	StatusFailed  Status = "failed"
+	// StatusInsufficientFunds is journaled when the balance couldn't cover the buy
+	StatusInsufficientFunds Status = "insufficient_funds"
+	// StatusSkipped is journaled for missed runs the policy chose not to catch up
+	StatusSkipped Status = "skipped"
+	// StatusUnknown replaces a pending execution found when the journal is loaded, the order
+	// may or may not have been placed so it is never retried
+	StatusUnknown Status = "unknown"
+)
+
+// Execution is a single journaled run of a plan
+type Execution struct {
+	Plan      string    `json:"plan"`
+	Scheduled time.Time `json:"scheduled"`
+	Executed  time.Time `json:"executed"`
+	Status    Status    `json:"status"`
+	OrderID   int       `json:"order_id,omitempty"`
+	Amount    float64   `json:"amount,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// journal is an append only JSON lines file of executions
+type journal struct {
+	file *os.File
+	// last execution of each plan and scheduled time
+	latest map[string]map[int64]*Execution
+	// lastScheduled is the latest scheduled time of each plan
+	lastScheduled map[string]time.Time
+}
+
+func openJournal(path string) (*journal, error) {
+	j := &journal{
+		latest:        make(map[string]map[int64]*Execution),
+		lastScheduled: make(map[string]time.Time),
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open journal: %s", err.Error())
+	}
+	j.file = f
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var execution Execution
+		if err = json.Unmarshal(scanner.Bytes(), &execution); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not decode journal: %s", err.Error())
+		}
+		j.track(&execution)
+	}
+	if err = scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not read journal: %s", err.Error())
+	}
+
+	// an execution left pending means the process stopped while placing an order
+	for _, executions := range j.latest {
+		for _, execution := range executions {
+			if execution.Status != StatusPending {
+				continue
+			}
+			unknown := *execution
+			unknown.Status = StatusUnknown
+			unknown.Executed = time.Now()
+			if err = j.append(&unknown); err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+	}
+
+	return j, nil
+}
+
+func (j *journal) track(execution *Execution) {
+	executions, ok := j.latest[execution.Plan]
+	if !ok {
+		executions = make(map[int64]*Execution)
+		j.latest[execution.Plan] = executions
+	}
+	executions[execution.Scheduled.UnixNano()] = execution
+
+	if execution.Scheduled.After(j.lastScheduled[execution.Plan]) {
+		j.lastScheduled[execution.Plan] = execution.Scheduled
+	}
+}
+
+// append will write an execution and sync it to disk before returning
+func (j *journal) append(execution *Execution) error {
+	b, err := json.Marshal(execution)
+	if err != nil {
+		return fmt.Errorf("could not encode execution: %s", err.Error())
+	}
+
+	if _, err = j.file.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("could not write journal: %s", err.Error())
+	}
+	if err = j.file.Sync(); err != nil {
+		return fmt.Errorf("could not sync journal: %s", err.Error())
+	}
+	j.track(execution)
+
+	return nil
+}
+
+// done reports whether a plan already has a final execution for a scheduled time
+func (j *journal) done(plan string, scheduled time.Time) bool {
+	execution, ok := j.latest[plan][scheduled.UnixNano()]
+	return ok && execution.Status != StatusRegistered
+}
+
+func (j *journal) close() error {
+	return j.file.Close()
+}
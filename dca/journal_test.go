@@ -0,0 +1,102 @@
+package dca_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx/dca"
+)
+
+func readJournal(t *testing.T, path string) []*dca.Execution {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var executions []*dca.Execution
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var execution dca.Execution
+		if err = json.Unmarshal(scanner.Bytes(), &execution); err != nil {
+			t.Fatal(err)
+		}
+		executions = append(executions, &execution)
+	}
+	if err = scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return executions
+}
+
+func TestJournalPendingIsUnknownOnRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+
+	registered := time.Date(2021, time.May, 1, 9, 0, 0, 0, time.UTC)
+	scheduled := registered.Add(time.Hour)
+	var lines []byte
+	for _, execution := range []*dca.Execution{
+		{Plan: "btc", Scheduled: registered, Executed: registered, Status: dca.StatusRegistered},
+		// the process stopped while placing this order
+		{Plan: "btc", Scheduled: scheduled, Executed: scheduled, Status: dca.StatusPending,
+			Amount: 100},
+	} {
+		b, err := json.Marshal(execution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(append(lines, b...), '\n')
+	}
+	if err = ioutil.WriteFile(path, lines, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := dca.Config{
+		Plans: []*dca.Plan{{Name: "btc", Primary: "AUD", Secondary: "BTC", Amount: 100,
+			Schedule: dca.Interval{Start: registered, Every: time.Hour}}},
+		JournalPath: path,
+		Policy:      dca.CatchUpAll,
+	}
+	// restarting twice must only mark the pending execution unknown once
+	for i := 0; i < 2; i++ {
+		s, err := dca.New(nil, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the unknown run is never retried, so nothing is due and no order is placed
+		executions, err := s.RunDue(scheduled.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(executions) != 0 {
+			t.Errorf("restart %d: expected nothing to run, got %+v", i, executions[0])
+		}
+		if err = s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	executions := readJournal(t, path)
+	if len(executions) != 3 {
+		t.Fatalf("expected 3 journal lines, got %d", len(executions))
+	}
+	unknown := executions[2]
+	if unknown.Status != dca.StatusUnknown || !unknown.Scheduled.Equal(scheduled) ||
+		unknown.Amount != 100 {
+		t.Errorf("expected the pending run to be unknown, got %+v", unknown)
+	}
+}
@@ -0,0 +1,72 @@
+package dca_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx/dca"
+)
+
+func TestCron(t *testing.T) {
+	tests := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"*/15 * * * *", time.Date(2020, 1, 1, 10, 7, 30, 0, time.UTC),
+			time.Date(2020, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC), // a wednesday
+			time.Date(2020, 1, 6, 9, 0, 0, 0, time.UTC)},
+		{"30 0 1 */3 *", time.Date(2020, 2, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 4, 1, 0, 30, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// both days restricted so either matches, the 15th is a wednesday
+		{"0 0 15 * 1", time.Date(2020, 1, 7, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 1, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 1", time.Date(2020, 1, 13, 12, 0, 0, 0, time.UTC),
+			time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)},
+		// a stepped day of month isn't a restriction so both must match, the first odd day
+		// that is a monday
+		{"0 0 */2 * 1", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 1, 13, 0, 0, 0, 0, time.UTC)},
+		// a stepped day of week with every day of the month
+		{"0 12 * * */3", time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC), // a wednesday
+			time.Date(2020, 1, 4, 12, 0, 0, 0, time.UTC)},
+		{"5-10/5 */6 * * *", time.Date(2020, 1, 1, 6, 6, 0, 0, time.UTC),
+			time.Date(2020, 1, 1, 6, 10, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		cron, err := dca.ParseCron(test.expr, time.UTC)
+		if err != nil {
+			t.Fatalf("%s: %s", test.expr, err)
+		}
+		if got := cron.Next(test.after); !got.Equal(test.want) {
+			t.Errorf("%s: expected %s, got %s", test.expr, test.want, got)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *",
+		"* * * 13 *", "5-1 * * * *", "a * * * *"} {
+		if _, err := dca.ParseCron(expr, nil); err == nil {
+			t.Errorf("expected %q to be invalid", expr)
+		}
+	}
+}
+
+func TestInterval(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	interval := dca.Interval{Start: start, Every: 24 * time.Hour}
+
+	if got := interval.Next(start.Add(-time.Hour)); !got.Equal(start) {
+		t.Errorf("expected %s, got %s", start, got)
+	}
+	if got, want := interval.Next(start.Add(36*time.Hour)), start.Add(48*time.Hour); !got.Equal(want) {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,265 @@
+package goswyftx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DepositState is how far a deposit has progressed
+type DepositState string
+
+// Deposit states. Swyftx doesn't report how many confirmations a deposit has, only its status,
+// so the states follow the status of the deposit in history. There is no confirmed state as
+// there is nothing to compare with the asset's minimum confirmations
+const (
+	// DepositSeen is a deposit in history that hasn't completed or failed yet
+	DepositSeen DepositState = "seen"
+	// DepositCredited is a deposit whose status is completed, which is when swyftx adds it to
+	// the available balance. The balance itself isn't checked
+	DepositCredited DepositState = "credited"
+	// DepositFailed is a deposit that was rejected or cancelled
+	DepositFailed DepositState = "failed"
+)
+
+// DepositEvent is a change in the state of a deposit
+type DepositEvent struct {
+	Time  time.Time
+	State DepositState
+	Asset string
+	// Address the deposit was made to, nil if it isn't one of the active addresses
+	Address *Address
+	Deposit *CurrencyHistory
+	// RequiredConfirmations is the asset's minimum confirmations before swyftx completes the
+	// deposit, the deposit's own confirmations aren't known
+	RequiredConfirmations int
+}
+
+// DepositWatcherConfig is what a deposit watcher watches
+type DepositWatcherConfig struct {
+	// Assets are the codes of the assets whose deposit addresses are watched
+	Assets []string
+	// OnEvent is called every time a deposit changes state
+	OnEvent func(event DepositEvent)
+	// OnError is called when a poll fails, it is optional
+	OnError func(err error)
+}
+
+// DepositWatcher polls the active deposit addresses of assets for incoming deposits and
+// follows them until they are credited
+type DepositWatcher struct {
+	client *Client
+	cfg    DepositWatcherConfig
+
+	mu      sync.Mutex
+	started map[string]bool
+	tracked map[string]DepositState
+}
+
+// NewDepositWatcher will create a deposit watcher for the configured assets
+func NewDepositWatcher(c *Client, cfg DepositWatcherConfig) (*DepositWatcher, error) {
+	if len(cfg.Assets) == 0 {
+		return nil, errors.New("no assets to watch")
+	}
+
+	return &DepositWatcher{
+		client:  c,
+		cfg:     cfg,
+		started: make(map[string]bool),
+		tracked: make(map[string]DepositState),
+	}, nil
+}
+
+// Run will poll every interval until ctx is done. Failed polls are passed to OnError and
+// tried again on the next interval
+func (dw *DepositWatcher) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := dw.Poll(ctx); err != nil && dw.cfg.OnError != nil {
+			dw.cfg.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll will ask swyftx to check every active deposit address, then read deposit history and
+// emit an event for each deposit that changed state. Deposits that had already settled before
+// the first successful poll of their asset are not reported. An asset that fails doesn't stop
+// the others from being polled, the failures are returned together
+func (dw *DepositWatcher) Poll(ctx context.Context) error {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+
+	client := dw.client.WithContext(ctx)
+	registry, err := client.Market().Registry()
+	if err != nil {
+		return fmt.Errorf("could not get assets: %s", err.Error())
+	}
+
+	var failed []string
+	for _, code := range dw.cfg.Assets {
+		asset, ok := registry.ByCode(code)
+		if !ok {
+			failed = append(failed, buildString("unknown asset: ", code))
+			continue
+		}
+		if err = dw.pollAsset(client, asset); err != nil {
+			failed = append(failed, buildString(asset.Code, ": ", err.Error()))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not check deposits of %d assets: %s", len(failed),
+			strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+func (dw *DepositWatcher) pollAsset(c *Client, asset *MarketAsset) error {
+	addressService := c.Address(asset.Code)
+	addresses, err := addressService.GetActive()
+	if err != nil {
+		return fmt.Errorf("could not get active addresses: %s", err.Error())
+	}
+	// a failed check only delays the deposit until the next poll so history is still read
+	var failed []string
+	for _, address := range addresses {
+		if err = addressService.CheckDeposit(address.ID); err != nil {
+			failed = append(failed, buildString("could not check address ",
+				strconv.Itoa(address.ID), ": ", err.Error()))
+		}
+	}
+
+	deposits, err := c.History(asset.ID).Deposit()
+	if err != nil {
+		failed = append(failed, buildString("could not get deposit history: ", err.Error()))
+	} else {
+		dw.update(asset, addresses, deposits)
+	}
+
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+// update will emit events for the deposits of an asset whose state changed since the last
+// poll
+func (dw *DepositWatcher) update(asset *MarketAsset, addresses []*Address,
+	deposits []*CurrencyHistory) {
+	code := strings.ToUpper(asset.Code)
+	for _, deposit := range deposits {
+		if deposit == nil || deposit.ID == 0 {
+			continue
+		}
+		key := buildString(code, "/", strconv.Itoa(deposit.ID))
+		state := depositState(deposit.Status)
+
+		current, ok := dw.tracked[key]
+		if !ok && !dw.started[code] && state != DepositSeen {
+			// already settled before the watcher started
+			dw.tracked[key] = state
+			continue
+		}
+		if current == DepositCredited || current == DepositFailed {
+			continue
+		}
+
+		dw.advance(key, state, asset, addresses, deposit)
+	}
+	dw.started[code] = true
+}
+
+// depositState is the state of a deposit with a history status
+func depositState(status string) DepositState {
+	switch ClassifyHistoryStatus(status) {
+	case HistoryStatusFailed:
+		return DepositFailed
+	case HistoryStatusCompleted:
+		return DepositCredited
+	}
+
+	return DepositSeen
+}
+
+// advance will move a deposit through every state up to state, emitting an event for each so
+// a deposit that is seen already credited still reports being seen. A failed
+// deposit goes straight to failed from whatever state it was in
+func (dw *DepositWatcher) advance(key string, state DepositState, asset *MarketAsset,
+	addresses []*Address, deposit *CurrencyHistory) {
+	current := dw.tracked[key]
+	if current == state {
+		return
+	}
+
+	order := []DepositState{DepositSeen, DepositCredited}
+	if state == DepositFailed {
+		order = []DepositState{DepositSeen, DepositFailed}
+		if current != "" {
+			order = order[1:]
+		}
+	} else if current != "" {
+		// only the states after the current one are left
+		for i, next := range order {
+			if next == current {
+				order = order[i+1:]
+				break
+			}
+		}
+	}
+	if !containsDepositState(order, state) {
+		// swyftx moved the deposit back a state, wait for it to catch up again
+		return
+	}
+
+	var address *Address
+	for _, a := range addresses {
+		if a.ID == deposit.AddressID {
+			address = a
+			break
+		}
+	}
+
+	for _, next := range order {
+		dw.tracked[key] = next
+		if dw.cfg.OnEvent != nil {
+			dw.cfg.OnEvent(DepositEvent{
+				Time:                  time.Now(),
+				State:                 next,
+				Asset:                 asset.Code,
+				Address:               address,
+				Deposit:               deposit,
+				RequiredConfirmations: asset.MinConfirmations,
+			})
+		}
+		if next == state {
+			return
+		}
+	}
+}
+
+func containsDepositState(states []DepositState, state DepositState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package goswyftx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDepositWatcherUpdate(t *testing.T) {
+	asset := &MarketAsset{ID: 3, Code: "btc", MinConfirmations: 2}
+	addresses := []*Address{{ID: 7}}
+	var events []DepositState
+	dw, err := NewDepositWatcher(nil, DepositWatcherConfig{
+		Assets: []string{"BTC"},
+		OnEvent: func(event DepositEvent) {
+			if event.Address != addresses[0] || event.RequiredConfirmations != 2 {
+				t.Errorf("unexpected event %+v", event)
+			}
+			events = append(events, event.State)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polls := []struct {
+		name     string
+		status   string
+		expected []DepositState
+	}{
+		{"pending", "Pending", []DepositState{DepositSeen}},
+		{"still pending", "Pending", nil},
+		// an unknown status isn't treated as any further along
+		{"unknown status", "Approved", nil},
+		{"credited", "Completed", []DepositState{DepositCredited}},
+		{"failed after crediting", "Failed", nil},
+	}
+	for _, poll := range polls {
+		events = nil
+		dw.update(asset, addresses, []*CurrencyHistory{
+			{ID: 1, AddressID: 7, Quantity: "1", Status: "Completed"},
+			{ID: 2, AddressID: 7, Quantity: "0.5", Status: poll.status},
+		})
+		if !reflect.DeepEqual(events, poll.expected) {
+			t.Errorf("%s: expected %v, got %v", poll.name, poll.expected, events)
+		}
+	}
+}
+
+func TestDepositWatcherAdvance(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  DepositState
+		state    DepositState
+		expected []DepositState
+	}{
+		{"seen credited", "", DepositCredited, []DepositState{DepositSeen, DepositCredited}},
+		{"pending to completed", DepositSeen, DepositCredited, []DepositState{DepositCredited}},
+		{"seen failed", "", DepositFailed, []DepositState{DepositSeen, DepositFailed}},
+		{"pending to failed", DepositSeen, DepositFailed, []DepositState{DepositFailed}},
+		{"unchanged", DepositSeen, DepositSeen, nil},
+		{"moved back", DepositCredited, DepositSeen, nil},
+	}
+
+	for _, test := range tests {
+		var events []DepositState
+		dw := &DepositWatcher{
+			cfg: DepositWatcherConfig{OnEvent: func(event DepositEvent) {
+				events = append(events, event.State)
+			}},
+			tracked: make(map[string]DepositState),
+		}
+		if test.current != "" {
+			dw.tracked["BTC/1"] = test.current
+		}
+
+		dw.advance("BTC/1", test.state, &MarketAsset{Code: "BTC"}, nil, &CurrencyHistory{ID: 1})
+		if !reflect.DeepEqual(events, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, events)
+		}
+		if len(test.expected) > 0 && dw.tracked["BTC/1"] != test.state {
+			t.Errorf("%s: expected the deposit to be %s, got %s", test.name, test.state,
+				dw.tracked["BTC/1"])
+		}
+	}
+}
+
+func TestDepositWatcherSettledBeforeStart(t *testing.T) {
+	var events []DepositState
+	dw, err := NewDepositWatcher(nil, DepositWatcherConfig{
+		Assets: []string{"BTC"},
+		OnEvent: func(event DepositEvent) {
+			events = append(events, event.State)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset := &MarketAsset{ID: 3, Code: "BTC"}
+	dw.update(asset, nil, []*CurrencyHistory{
+		{ID: 1, Status: "Completed"},
+		{ID: 2, Status: "Failed"},
+	})
+	if len(events) != 0 {
+		t.Errorf("expected settled deposits not to be reported, got %v", events)
+	}
+
+	// a deposit that appears after the first poll is reported even if it is already credited
+	dw.update(asset, nil, []*CurrencyHistory{{ID: 3, Status: "Completed"}})
+	expected := []DepositState{DepositSeen, DepositCredited}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %v, got %v", expected, events)
+	}
+}
+
+func TestDepositWatcherRunRejectsInterval(t *testing.T) {
+	dw, err := NewDepositWatcher(nil, DepositWatcherConfig{Assets: []string{"BTC"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = dw.Run(context.Background(), 0); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
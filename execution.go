@@ -0,0 +1,540 @@
+package goswyftx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSlippageExceeded is returned when a quote deviates from the reference price by more
+	// than the allowed slippage
+	ErrSlippageExceeded = errors.New("quote exceeds the allowed slippage")
+
+	errExecutionQuantity = errors.New("quantity is too small to split into orders")
+)
+
+// defaultIcebergPoll is how often an iceberg checks whether its visible order has filled
+const defaultIcebergPoll = 5 * time.Second
+
+// ExecutionState is the state of an execution algorithm
+type ExecutionState string
+
+// Execution states
+const (
+	ExecutionRunning   ExecutionState = "running"
+	ExecutionPaused    ExecutionState = "paused"
+	ExecutionCancelled ExecutionState = "cancelled"
+	ExecutionDone      ExecutionState = "done"
+	ExecutionFailed    ExecutionState = "failed"
+)
+
+// ParentOrder is a large order that an execution algorithm splits into child orders
+type ParentOrder struct {
+	// Primary is the asset paid or received e.g. AUD and Secondary is the asset traded e.g. BTC
+	Primary   string
+	Secondary string
+	Buy       bool
+	// Quantity of the secondary asset to trade
+	Quantity float64
+	// Asset holds the minimum order and increment of the secondary asset, it is optional
+	Asset *MarketAsset
+	// MaxSlippage is the fraction a quote can deviate from the first quote before the
+	// execution pauses itself, zero disables the check. Child orders are quoted for their
+	// quantity rounded up to a whole unit, see Quote
+	MaxSlippage float64
+	// OnProgress is called after every child order, it is optional
+	OnProgress func(ExecutionProgress)
+}
+
+// ExecutionProgress is a snapshot of how far an execution has got
+type ExecutionProgress struct {
+	State ExecutionState
+	// Placed is the quantity of child orders placed and Remaining is what is left to place
+	Placed    float64
+	Remaining float64
+	// ReferencePrice is the price of the first quote, slippage is measured against it
+	ReferencePrice float64
+	ChildOrderIDs  []int
+	// Err is why the execution failed or last paused itself
+	Err error
+}
+
+// Execution is a running execution algorithm that can be paused, resumed and cancelled
+type Execution struct {
+	os *OrderService
+	// cleanup isn't bound to the execution's context so orders can still be cancelled once
+	// it is done
+	cleanup *OrderService
+	parent  ParentOrder
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu       sync.Mutex
+	progress ExecutionProgress
+	resume   chan struct{}
+	pausedAt time.Time
+	// paused is how long the execution has spent paused before pausedAt
+	paused time.Duration
+}
+
+// TWAPConfig spreads a parent order evenly over a duration
+type TWAPConfig struct {
+	Duration time.Duration
+	// Slices is the number of child orders, it is reduced if the slices would be smaller
+	// than the asset's minimum order
+	Slices int
+}
+
+// IcebergConfig only shows part of a parent order at a time
+type IcebergConfig struct {
+	// VisibleQuantity is the size of each child order
+	VisibleQuantity float64
+	// LimitPrice places limit child orders at this price, if zero market orders are used. It
+	// must be a whole number as that is all an order trigger holds
+	LimitPrice float64
+	// PollInterval is how often to check whether a child order has filled, defaults to 5
+	// seconds if zero
+	PollInterval time.Duration
+}
+
+// TWAP will split a parent order into equal market orders placed evenly over the configured
+// duration. Time spent paused moves the remaining slices back so they aren't all placed at
+// once when the execution resumes. The execution runs until it is done, cancelled or ctx is
+// done
+func (os *OrderService) TWAP(ctx context.Context, parent ParentOrder,
+	cfg TWAPConfig) (*Execution, error) {
+	if cfg.Slices <= 0 || cfg.Duration < 0 {
+		return nil, errors.New("twap needs at least one slice and a non negative duration")
+	}
+
+	slices, err := splitQuantity(parent.Quantity, cfg.Slices, parent.Asset)
+	if err != nil {
+		return nil, err
+	}
+
+	e := newExecution(ctx, os, parent)
+	interval := cfg.Duration / time.Duration(len(slices))
+	go e.run(func(ctx context.Context) error {
+		start := time.Now()
+		for i, quantity := range slices {
+			if err := e.sleepUntilUnpaused(ctx, start.Add(time.Duration(i)*interval)); err != nil {
+				return err
+			}
+			if _, err := e.placeChild(ctx, quantity, marketOrderType(parent.Buy), 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return e, nil
+}
+
+// Iceberg will place child orders of the visible quantity one at a time, waiting for each to
+// complete before placing the next. The execution runs until it is done, cancelled or ctx is
+// done
+func (os *OrderService) Iceberg(ctx context.Context, parent ParentOrder,
+	cfg IcebergConfig) (*Execution, error) {
+	if cfg.VisibleQuantity <= 0 {
+		return nil, errors.New("iceberg needs a positive visible quantity")
+	}
+	if cfg.PollInterval < 0 {
+		return nil, errors.New("iceberg needs a positive poll interval")
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaultIcebergPoll
+	}
+	if cfg.LimitPrice < 0 {
+		return nil, errors.New("iceberg needs a positive limit price")
+	}
+
+	n := int(math.Ceil(parent.Quantity / cfg.VisibleQuantity))
+	slices, err := splitQuantity(parent.Quantity, n, parent.Asset)
+	if err != nil {
+		return nil, err
+	}
+
+	orderType, trigger := marketOrderType(parent.Buy), 0
+	if cfg.LimitPrice > 0 {
+		if trigger, err = orderTrigger(cfg.LimitPrice); err != nil {
+			return nil, err
+		}
+		orderType = OrderTypeLimitSell
+		if parent.Buy {
+			orderType = OrderTypeLimitBuy
+		}
+	}
+
+	e := newExecution(ctx, os, parent)
+	go e.run(func(ctx context.Context) error {
+		for _, quantity := range slices {
+			id, err := e.placeChild(ctx, quantity, orderType, trigger)
+			if err != nil {
+				return err
+			}
+			if err = e.waitForFill(ctx, id, cfg.PollInterval); err != nil {
+				if ctx.Err() == nil {
+					return err
+				}
+				// the execution was stopped so the visible order shouldn't keep resting
+				if cancelErr := e.cleanup.Cancel(id); cancelErr != nil {
+					return fmt.Errorf("could not cancel child order %d: %s", id,
+						cancelErr.Error())
+				}
+				return err
+			}
+		}
+		return nil
+	})
+
+	return e, nil
+}
+
+func newExecution(ctx context.Context, os *OrderService, parent ParentOrder) *Execution {
+	ctx, cancel := context.WithCancel(ctx)
+	e := &Execution{
+		os:      (*OrderService)(&service{os.client.WithContext(ctx)}),
+		cleanup: (*OrderService)(&service{os.client.WithContext(context.Background())}),
+		parent:  parent,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		progress: ExecutionProgress{
+			State:     ExecutionRunning,
+			Remaining: parent.Quantity,
+		},
+	}
+
+	return e
+}
+
+func (e *Execution) run(algorithm func(ctx context.Context) error) {
+	defer close(e.done)
+	defer e.cancel()
+
+	err := algorithm(e.os.client.ctx)
+
+	e.mu.Lock()
+	switch {
+	case e.progress.State == ExecutionCancelled:
+		if err != nil && !errors.Is(err, context.Canceled) {
+			e.progress.Err = err
+		}
+	case err == nil:
+		e.progress.State = ExecutionDone
+	default:
+		e.progress.State = ExecutionFailed
+		e.progress.Err = err
+	}
+	progress := e.snapshot()
+	e.mu.Unlock()
+
+	e.report(progress)
+}
+
+// placeChild will wait while the execution is paused, check the quote is within the allowed
+// slippage and place a child order
+func (e *Execution) placeChild(ctx context.Context, quantity float64, orderType string,
+	trigger int) (int, error) {
+	for {
+		if err := e.waitIfPaused(ctx); err != nil {
+			return 0, err
+		}
+
+		err := e.checkSlippage(quantity)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrSlippageExceeded) {
+			return 0, err
+		}
+
+		// pause until someone decides to resume, at which point the quote is checked again
+		e.mu.Lock()
+		e.pause()
+		e.progress.Err = err
+		progress := e.snapshot()
+		e.mu.Unlock()
+		e.report(progress)
+	}
+
+	id, err := e.os.Place(&OrderPlace{
+		Primary:       e.parent.Primary,
+		Secondary:     e.parent.Secondary,
+		Quantity:      float32(quantity),
+		AssetQuantity: e.parent.Secondary,
+		OrderType:     orderType,
+		Trigger:       trigger,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not place child order: %s", err.Error())
+	}
+
+	e.mu.Lock()
+	e.progress.Placed += quantity
+	e.progress.Remaining = math.Max(0, e.parent.Quantity-e.progress.Placed)
+	e.progress.ChildOrderIDs = append(e.progress.ChildOrderIDs, id)
+	progress := e.snapshot()
+	e.mu.Unlock()
+	e.report(progress)
+
+	return id, nil
+}
+
+func (e *Execution) checkSlippage(quantity float64) error {
+	if e.parent.MaxSlippage <= 0 {
+		return nil
+	}
+
+	buy, sell := e.parent.Secondary, e.parent.Primary
+	if !e.parent.Buy {
+		buy, sell = sell, buy
+	}
+	rate, err := e.os.PairExchangeRate(buy, sell, quoteAmount(quantity), e.parent.Secondary)
+	if err != nil {
+		return fmt.Errorf("could not get quote: %s", err.Error())
+	}
+	price, err := quotePrice(rate, e.parent.Buy)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.progress.ReferencePrice == 0 {
+		e.progress.ReferencePrice = price
+		return nil
+	}
+	if deviation := math.Abs(price-e.progress.ReferencePrice) / e.progress.ReferencePrice; deviation > e.parent.MaxSlippage {
+		return fmt.Errorf("%w: price %g is %.2f%% from %g", ErrSlippageExceeded, price,
+			deviation*100, e.progress.ReferencePrice)
+	}
+
+	return nil
+}
+
+func (e *Execution) waitForFill(ctx context.Context, orderID int, poll time.Duration) error {
+	if poll <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		orders, err := e.os.List(e.parent.Secondary)
+		if err != nil {
+			return fmt.Errorf("could not list orders: %s", err.Error())
+		}
+		for _, order := range orders {
+			if order.ID != orderID {
+				continue
+			}
+			switch order.Status {
+			case OrderStatusCompleted:
+				return nil
+			case OrderStatusCancelled, OrderStatusFailed, OrderStatusExpired,
+				OrderStatusSystemCancelled:
+				return fmt.Errorf("child order %d did not fill, status %s", orderID, order.Status)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Execution) waitIfPaused(ctx context.Context) error {
+	for {
+		e.mu.Lock()
+		if e.progress.State != ExecutionPaused {
+			e.mu.Unlock()
+			return nil
+		}
+		resume := e.resume
+		e.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resume:
+		}
+	}
+}
+
+// sleepUntilUnpaused will sleep until t moved back by the time the execution has spent paused,
+// waiting out any pause before returning
+func (e *Execution) sleepUntilUnpaused(ctx context.Context, t time.Time) error {
+	for {
+		if err := e.waitIfPaused(ctx); err != nil {
+			return err
+		}
+
+		e.mu.Lock()
+		due := t.Add(e.paused)
+		e.mu.Unlock()
+		if !time.Now().Before(due) {
+			return ctx.Err()
+		}
+		if err := sleepUntil(ctx, due); err != nil {
+			return err
+		}
+	}
+}
+
+// must be called with the lock held
+func (e *Execution) pause() {
+	if e.progress.State != ExecutionRunning {
+		return
+	}
+	e.progress.State = ExecutionPaused
+	e.resume = make(chan struct{})
+	e.pausedAt = time.Now()
+}
+
+// must be called with the lock held
+func (e *Execution) snapshot() ExecutionProgress {
+	progress := e.progress
+	progress.ChildOrderIDs = append([]int(nil), e.progress.ChildOrderIDs...)
+	return progress
+}
+
+func (e *Execution) report(progress ExecutionProgress) {
+	if e.parent.OnProgress != nil {
+		e.parent.OnProgress(progress)
+	}
+}
+
+// Pause will stop placing child orders until Resume is called, an order already being placed
+// is not stopped
+func (e *Execution) Pause() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pause()
+}
+
+// Resume will continue a paused execution
+func (e *Execution) Resume() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.progress.State != ExecutionPaused {
+		return
+	}
+	e.progress.State = ExecutionRunning
+	e.paused += time.Since(e.pausedAt)
+	close(e.resume)
+}
+
+// Cancel will stop the execution. An iceberg's child order that is waiting to fill is
+// cancelled and Wait returns an error if it couldn't be, other child orders already placed
+// are not cancelled
+func (e *Execution) Cancel() {
+	e.mu.Lock()
+	if e.progress.State == ExecutionRunning || e.progress.State == ExecutionPaused {
+		e.progress.State = ExecutionCancelled
+	}
+	e.mu.Unlock()
+
+	e.cancel()
+}
+
+// Progress is a snapshot of the execution's progress
+func (e *Execution) Progress() ExecutionProgress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.snapshot()
+}
+
+// Done is closed once the execution has stopped
+func (e *Execution) Done() <-chan struct{} {
+	return e.done
+}
+
+// Wait will block until the execution stops and return why it failed, if it did
+func (e *Execution) Wait() error {
+	<-e.done
+	return e.Progress().Err
+}
+
+func marketOrderType(buy bool) string {
+	if buy {
+		return OrderTypeMarketBuy
+	}
+	return OrderTypeMarketSell
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// splitQuantity will split quantity into at most n slices rounded down to the asset's
+// increment, using fewer slices if needed so none is below the asset's minimum order. Any
+// rounding is added to the last slice, less than an increment is left over if quantity isn't
+// a multiple of it
+func splitQuantity(quantity float64, n int, asset *MarketAsset) ([]float64, error) {
+	var minOrder, increment float64
+	if asset != nil {
+		var err error
+		if minOrder, err = parseFloat(asset.MinimumOrder); err != nil {
+			return nil, fmt.Errorf("could not parse minimum order: %s", err.Error())
+		}
+		increment = asset.Increment()
+	}
+
+	if quantity <= 0 || quantity < minOrder {
+		return nil, errExecutionQuantity
+	}
+	if minOrder > 0 {
+		if most := int(quantity / minOrder); most < n {
+			n = most
+		}
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	slices := make([]float64, n)
+	if increment <= 0 {
+		slice := quantity / float64(n)
+		for i := range slices {
+			slices[i] = slice
+		}
+		slices[n-1] = quantity - slice*float64(n-1)
+		return slices, nil
+	}
+
+	// work in whole increments so every slice, including the last, is a multiple of one
+	units := int64(math.Floor(quantity/increment + 1e-9))
+	per := units / int64(n)
+	if per <= 0 {
+		return nil, errExecutionQuantity
+	}
+	for i := range slices {
+		slices[i] = float64(per) * increment
+	}
+	slices[n-1] = float64(units-per*int64(n-1)) * increment
+
+	return slices, nil
+}
@@ -0,0 +1,157 @@
+package goswyftx
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSplitQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity float64
+		n        int
+		asset    *MarketAsset
+		expected []float64
+		err      bool
+	}{
+		{name: "no asset", quantity: 10, n: 4, expected: []float64{2.5, 2.5, 2.5, 2.5}},
+		{name: "rounding goes to the last slice", quantity: 10, n: 3,
+			asset:    &MarketAsset{MinimumOrder: "0", MinimumOrderIncrement: 1},
+			expected: []float64{3, 3, 4}},
+		{name: "the last slice is a multiple of the increment", quantity: 1.005, n: 2,
+			asset:    &MarketAsset{MinimumOrder: "0", MinimumOrderIncrement: 0.01},
+			expected: []float64{0.5, 0.5}},
+		{name: "fewer slices than the minimum order allows", quantity: 10, n: 5,
+			asset:    &MarketAsset{MinimumOrder: "3", MinimumOrderIncrement: 1},
+			expected: []float64{3, 3, 4}},
+		{name: "below the minimum order", quantity: 1, n: 2,
+			asset: &MarketAsset{MinimumOrder: "2"}, err: true},
+		{name: "smaller than an increment", quantity: 0.5, n: 1,
+			asset: &MarketAsset{MinimumOrder: "0", MinimumOrderIncrement: 1}, err: true},
+		{name: "zero", quantity: 0, n: 1, err: true},
+	}
+
+	for _, test := range tests {
+		slices, err := splitQuantity(test.quantity, test.n, test.asset)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", test.name, slices)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+		if len(slices) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, slices)
+			continue
+		}
+		for i := range slices {
+			if math.Abs(slices[i]-test.expected[i]) > 1e-9 {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, slices)
+				break
+			}
+		}
+	}
+}
+
+func TestQuoteAmount(t *testing.T) {
+	for quantity, expected := range map[float64]int{0.2: 1, 1: 1, 2.7: 3, 10: 10} {
+		if amount := quoteAmount(quantity); amount != expected {
+			t.Errorf("%g: expected %d, got %d", quantity, expected, amount)
+		}
+	}
+}
+
+func TestOrderTrigger(t *testing.T) {
+	for price, expected := range map[float64]int{1: 1, 42: 42, 65000: 65000} {
+		trigger, err := orderTrigger(price)
+		if err != nil {
+			t.Errorf("%g: %s", price, err.Error())
+			continue
+		}
+		if trigger != expected {
+			t.Errorf("%g: expected %d, got %d", price, expected, trigger)
+		}
+	}
+
+	for _, price := range []float64{0, -5, 0.6, 1.5, 65000.01, math.MaxInt64} {
+		if trigger, err := orderTrigger(price); err == nil {
+			t.Errorf("%g: expected an error, got %d", price, trigger)
+		}
+	}
+}
+
+func TestIcebergRejectsConfig(t *testing.T) {
+	os := (&Client{}).Order()
+	parent := ParentOrder{Primary: "AUD", Secondary: "BTC", Buy: true, Quantity: 1}
+
+	tests := []struct {
+		name string
+		cfg  IcebergConfig
+	}{
+		{name: "fractional limit", cfg: IcebergConfig{VisibleQuantity: 0.5, LimitPrice: 0.6}},
+		{name: "limit off a whole number", cfg: IcebergConfig{VisibleQuantity: 0.5,
+			LimitPrice: 65000.5}},
+		{name: "negative limit", cfg: IcebergConfig{VisibleQuantity: 0.5, LimitPrice: -1}},
+		{name: "negative poll", cfg: IcebergConfig{VisibleQuantity: 0.5,
+			PollInterval: -time.Second}},
+	}
+
+	for _, test := range tests {
+		if e, err := os.Iceberg(context.Background(), parent, test.cfg); err == nil {
+			e.Cancel()
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func TestSleepUntilUnpaused(t *testing.T) {
+	tests := []struct {
+		name    string
+		pauseAt time.Duration
+		due     time.Duration
+		// the wait is at least the due time plus the time spent paused
+		min time.Duration
+	}{
+		{name: "paused before sleeping", pauseAt: 0, due: 20 * time.Millisecond,
+			min: 80 * time.Millisecond},
+		{name: "paused while sleeping", pauseAt: 10 * time.Millisecond,
+			due: 40 * time.Millisecond, min: 100 * time.Millisecond},
+		{name: "a missed slice is placed straight away", pauseAt: -1},
+	}
+
+	for _, test := range tests {
+		e := &Execution{progress: ExecutionProgress{State: ExecutionRunning}}
+		start := time.Now()
+		if test.pauseAt == 0 {
+			e.Pause()
+		}
+		if test.pauseAt >= 0 {
+			go func(pauseAt time.Duration) {
+				if pauseAt > 0 {
+					time.Sleep(pauseAt)
+					e.Pause()
+				}
+				time.Sleep(60 * time.Millisecond)
+				e.Resume()
+			}(test.pauseAt)
+		}
+
+		due := start.Add(test.due)
+		if test.pauseAt < 0 {
+			due = start.Add(-time.Minute)
+		}
+		if err := e.sleepUntilUnpaused(context.Background(), due); err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+		if elapsed := time.Since(start); elapsed < test.min ||
+			(test.pauseAt < 0 && elapsed > 50*time.Millisecond) {
+			t.Errorf("%s: waited %s", test.name, elapsed)
+		}
+	}
+}
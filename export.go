@@ -0,0 +1,324 @@
+package goswyftx
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ExportFormat is a file format that records can be exported to
+type ExportFormat int
+
+// Supported export formats
+const (
+	CSV ExportFormat = iota
+	JSONLines
+	Parquet
+)
+
+var (
+	errExportMixed = errors.New("cannot export different record types to the same writer")
+	errExportNil   = errors.New("cannot export a nil record")
+)
+
+// ExportColumnType is the type of the values in an exported column
+type ExportColumnType int
+
+// Export column types. Integer and decimal columns are written as JSON numbers and as INT64
+// and DECIMAL(38, 18) parquet columns, an empty value is written as a null
+const (
+	ExportString ExportColumnType = iota
+	ExportInteger
+	ExportDecimal
+)
+
+// ExportColumn describes a column of an exported record
+type ExportColumn struct {
+	Name string
+	// Type of the column, values are always written to JSON exactly as swyftx returned them
+	// so no decimal precision is lost
+	Type ExportColumnType
+}
+
+// ExportRecord is a row that can be exported
+type ExportRecord interface {
+	ExportColumns() []ExportColumn
+	ExportValues() []string
+}
+
+// Exporter streams records to a writer one at a time so large exports never need to be held
+// in memory. Every record written to an exporter must be of the same type
+type Exporter struct {
+	w       io.Writer
+	format  ExportFormat
+	record  reflect.Type
+	columns []ExportColumn
+	csv     *csv.Writer
+	parquet *parquetWriter
+}
+
+// NewExporter will create an exporter that writes records to w in the given format, Close
+// must be called once all records have been written
+func NewExporter(w io.Writer, format ExportFormat) *Exporter {
+	return &Exporter{w: w, format: format}
+}
+
+// Write will export a single record, a nil record is rejected
+func (e *Exporter) Write(record ExportRecord) error {
+	if record == nil {
+		return errExportNil
+	}
+	if v := reflect.ValueOf(record); v.Kind() == reflect.Ptr && v.IsNil() {
+		return errExportNil
+	}
+
+	if e.record == nil {
+		e.record = reflect.TypeOf(record)
+		if err := e.start(record.ExportColumns()); err != nil {
+			return err
+		}
+	} else if reflect.TypeOf(record) != e.record {
+		return errExportMixed
+	}
+
+	values := record.ExportValues()
+	if len(values) != len(e.columns) {
+		return fmt.Errorf("%s has %d values for %d columns", e.record, len(values),
+			len(e.columns))
+	}
+
+	switch e.format {
+	case CSV:
+		return e.csv.Write(values)
+	case JSONLines:
+		return e.writeJSONLine(values)
+	case Parquet:
+		return e.parquet.writeRow(values)
+	}
+
+	return fmt.Errorf("unknown export format: %d", e.format)
+}
+
+func (e *Exporter) start(columns []ExportColumn) error {
+	e.columns = columns
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+
+	switch e.format {
+	case CSV:
+		e.csv = csv.NewWriter(e.w)
+		return e.csv.Write(names)
+	case Parquet:
+		var err error
+		e.parquet, err = newParquetWriter(e.w, columns)
+		return err
+	}
+
+	return nil
+}
+
+func (e *Exporter) writeJSONLine(values []string) error {
+	b := []byte{'{'}
+	for i, column := range e.columns {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuote(b, column.Name)
+		b = append(b, ':')
+
+		numeric := column.Type != ExportString
+		switch {
+		case numeric && values[i] == "":
+			b = append(b, "null"...)
+		case numeric:
+			// validate the number so a bad value can't produce invalid JSON
+			if err := json.Unmarshal([]byte(values[i]), new(json.Number)); err != nil {
+				return fmt.Errorf("column %s is not a number: %q", column.Name, values[i])
+			}
+			b = append(b, values[i]...)
+		default:
+			value, err := json.Marshal(values[i])
+			if err != nil {
+				return err
+			}
+			b = append(b, value...)
+		}
+	}
+	b = append(b, '}', '\n')
+
+	_, err := e.w.Write(b)
+	return err
+}
+
+// Close will flush any buffered records, it does not close the underlying writer
+func (e *Exporter) Close() error {
+	switch {
+	case e.csv != nil:
+		e.csv.Flush()
+		return e.csv.Error()
+	case e.parquet != nil:
+		return e.parquet.close()
+	case e.format == Parquet:
+		// nothing was written but the file still needs to be a valid parquet file
+		var err error
+		if e.parquet, err = newParquetWriter(e.w, nil); err != nil {
+			return err
+		}
+		return e.parquet.close()
+	}
+
+	return nil
+}
+
+// ExportBars will export bars to w in the given format
+func ExportBars(w io.Writer, format ExportFormat, bars []*OCHLVT) error {
+	e := NewExporter(w, format)
+	for _, bar := range bars {
+		if err := e.Write(bar); err != nil {
+			return err
+		}
+	}
+
+	return e.Close()
+}
+
+// ExportTransactions will export transaction history to w in the given format
+func ExportTransactions(w io.Writer, format ExportFormat, history []*TransactionHistory) error {
+	e := NewExporter(w, format)
+	for _, transaction := range history {
+		if err := e.Write(transaction); err != nil {
+			return err
+		}
+	}
+
+	return e.Close()
+}
+
+// ExportOrders will export orders to w in the given format
+func ExportOrders(w io.Writer, format ExportFormat, orders []*Order) error {
+	e := NewExporter(w, format)
+	for _, order := range orders {
+		if err := e.Write(order); err != nil {
+			return err
+		}
+	}
+
+	return e.Close()
+}
+
+// ExportBalances will export account balances to w in the given format
+func ExportBalances(w io.Writer, format ExportFormat, balances []*AccountBalance) error {
+	e := NewExporter(w, format)
+	for _, balance := range balances {
+		if err := e.Write(balance); err != nil {
+			return err
+		}
+	}
+
+	return e.Close()
+}
+
+// ISO8601 will format the time in UTC as an ISO-8601 timestamp, a zero time is formatted as
+// an empty string
+func (s SwyftxTime) ISO8601() string {
+	if s.IsZero() {
+		return ""
+	}
+
+	return s.UTC().Format(time.RFC3339Nano)
+}
+
+func formatFloat32(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+// ExportColumns of a bar
+func (o *OCHLVT) ExportColumns() []ExportColumn {
+	return []ExportColumn{
+		{"time", ExportString},
+		{"open", ExportDecimal},
+		{"high", ExportDecimal},
+		{"low", ExportDecimal},
+		{"close", ExportDecimal},
+		{"volume", ExportInteger},
+	}
+}
+
+// ExportValues of a bar
+func (o *OCHLVT) ExportValues() []string {
+	return []string{o.Time.ISO8601(), o.Open, o.High, o.Low, o.Close, strconv.Itoa(o.Volume)}
+}
+
+// ExportColumns of a transaction
+func (t *TransactionHistory) ExportColumns() []ExportColumn {
+	return []ExportColumn{
+		{"updated", ExportString},
+		{"asset", ExportInteger},
+		{"amount", ExportDecimal},
+		{"action_type", ExportString},
+		{"status", ExportString},
+	}
+}
+
+// ExportValues of a transaction
+func (t *TransactionHistory) ExportValues() []string {
+	return []string{t.Updated.ISO8601(), strconv.Itoa(t.Asset), formatFloat32(t.Amount), t.ActionType,
+		t.Status}
+}
+
+// ExportColumns of an order
+func (o *Order) ExportColumns() []ExportColumn {
+	return []ExportColumn{
+		{"id", ExportInteger},
+		{"created_time", ExportString},
+		{"order_type", ExportString},
+		{"primary_asset", ExportString},
+		{"secondary_asset", ExportString},
+		{"quantity_asset", ExportString},
+		{"quantity", ExportInteger},
+		{"trigger", ExportInteger},
+		{"status", ExportString},
+		{"amount", ExportInteger},
+		{"total", ExportDecimal},
+		{"price", ExportInteger},
+	}
+}
+
+// ExportValues of an order
+func (o *Order) ExportValues() []string {
+	return []string{
+		strconv.Itoa(o.ID),
+		o.CreateTime.ISO8601(),
+		o.Type,
+		o.PrimaryAsset,
+		o.SecondaryAsset,
+		o.QuantityAsset,
+		strconv.Itoa(o.Quantity),
+		strconv.Itoa(o.Trigger),
+		o.Status,
+		strconv.Itoa(o.Amount),
+		formatFloat32(o.Total),
+		strconv.Itoa(o.Price),
+	}
+}
+
+// ExportColumns of an account balance
+func (a *AccountBalance) ExportColumns() []ExportColumn {
+	return []ExportColumn{
+		{"asset_id", ExportInteger},
+		{"available_balance", ExportDecimal},
+	}
+}
+
+// ExportValues of an account balance
+func (a *AccountBalance) ExportValues() []string {
+	return []string{strconv.Itoa(a.AssetID), a.AvailableBalance}
+}
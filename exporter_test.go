@@ -0,0 +1,426 @@
+package goswyftx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// thriftReader decodes the thrift compact protocol into field ID to value maps so the
+// metadata written by parquetWriter can be checked without a parquet library
+type thriftReader struct {
+	b   []byte
+	err error
+}
+
+func (r *thriftReader) byte() byte {
+	if len(r.b) == 0 {
+		r.err = errors.New("unexpected end of thrift data")
+		return 0
+	}
+	b := r.b[0]
+	r.b = r.b[1:]
+	return b
+}
+
+func (r *thriftReader) varint() uint64 {
+	n, size := binary.Uvarint(r.b)
+	if size <= 0 {
+		r.err = errors.New("bad varint")
+		return 0
+	}
+	r.b = r.b[size:]
+	return n
+}
+
+func (r *thriftReader) int() int64 {
+	n := r.varint()
+	return int64(n>>1) ^ -int64(n&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.int()
+	case thriftBinary:
+		n := int(r.varint())
+		if n > len(r.b) {
+			r.err = errors.New("binary longer than thrift data")
+			return nil
+		}
+		s := string(r.b[:n])
+		r.b = r.b[n:]
+		return s
+	case thriftList:
+		header := r.byte()
+		size, elemType := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, 0, size)
+		for i := 0; i < size && r.err == nil; i++ {
+			list = append(list, r.value(elemType))
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+
+	r.err = errors.New("unsupported thrift type " + strconv.Itoa(int(typ)))
+	return nil
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for r.err == nil {
+		header := r.byte()
+		if header == 0 {
+			break
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.int())
+		}
+		fields[id] = r.value(header & 0x0f)
+	}
+
+	return fields
+}
+
+// parquetFile is a parquet file written by parquetWriter read back into its schema and rows
+type parquetFile struct {
+	schema []map[int16]interface{}
+	rows   int64
+	// columns are the values of each column, a null is nil
+	columns [][]interface{}
+}
+
+func readParquet(t *testing.T, b []byte) *parquetFile {
+	t.Helper()
+
+	if len(b) < 12 || !bytes.Equal(b[:4], parquetMagic) || !bytes.Equal(b[len(b)-4:], parquetMagic) {
+		t.Fatal("expected the file to start and end with PAR1")
+	}
+	length := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	if length > len(b)-12 {
+		t.Fatalf("footer length %d is longer than the file", length)
+	}
+	footer := &thriftReader{b: b[len(b)-8-length : len(b)-8]}
+	meta := footer.structure()
+	if footer.err != nil {
+		t.Fatal(footer.err)
+	}
+	if len(footer.b) != 0 {
+		t.Fatalf("%d bytes left after the footer", len(footer.b))
+	}
+
+	file := &parquetFile{rows: meta[3].(int64)}
+	for _, element := range meta[2].([]interface{})[1:] {
+		file.schema = append(file.schema, element.(map[int16]interface{}))
+	}
+	file.columns = make([][]interface{}, len(file.schema))
+
+	for _, group := range meta[4].([]interface{}) {
+		for i, chunk := range group.(map[int16]interface{})[1].([]interface{}) {
+			chunkMeta := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+			offset := chunkMeta[9].(int64)
+			page := &thriftReader{b: b[offset:]}
+			header := page.structure()
+			if page.err != nil {
+				t.Fatal(page.err)
+			}
+			data := page.b[:header[2].(int64)]
+			numValues := int(header[5].(map[int16]interface{})[1].(int64))
+			file.columns[i] = append(file.columns[i], readParquetPage(t, file.schema[i], data,
+				numValues)...)
+		}
+	}
+
+	return file
+}
+
+func readParquetPage(t *testing.T, schema map[int16]interface{}, data []byte,
+	numValues int) []interface{} {
+	t.Helper()
+
+	defined := make([]bool, numValues)
+	if schema[3].(int64) == parquetOptional {
+		length := int(binary.LittleEndian.Uint32(data))
+		levels := &thriftReader{b: data[4 : 4+length]}
+		for i := 0; i < numValues; {
+			run := int(levels.varint())
+			if run&1 != 0 {
+				t.Fatal("expected definition levels to be RLE runs")
+			}
+			value := levels.byte()
+			for j := 0; j < run>>1; j++ {
+				defined[i+j] = value == 1
+			}
+			i += run >> 1
+		}
+		data = data[4+length:]
+	} else {
+		for i := range defined {
+			defined[i] = true
+		}
+	}
+
+	values := make([]interface{}, numValues)
+	for i := range values {
+		if !defined[i] {
+			continue
+		}
+		switch schema[1].(int64) {
+		case parquetInt64:
+			values[i] = int64(binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		case parquetFixedLenByteArray:
+			size := int(schema[2].(int64))
+			unscaled := new(big.Int).SetBytes(data[:size])
+			if data[0]&0x80 != 0 {
+				unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+			}
+			scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(schema[7].(int64)), nil)
+			values[i] = new(big.Rat).SetFrac(unscaled, scale).FloatString(int(schema[7].(int64)))
+			data = data[size:]
+		default:
+			length := int(binary.LittleEndian.Uint32(data))
+			values[i] = string(data[4 : 4+length])
+			data = data[4+length:]
+		}
+	}
+
+	return values
+}
+
+func TestExportParquetRoundTrip(t *testing.T) {
+	balances := []*AccountBalance{
+		{AssetID: 1, AvailableBalance: "1234.5"},
+		{AssetID: 3, AvailableBalance: ""},
+		{AssetID: 5, AvailableBalance: "-0.0000000000000000005"},
+	}
+	var buf bytes.Buffer
+	if err := ExportBalances(&buf, Parquet, balances); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "balances.parquet")
+	if *updateGolden {
+		if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Error("expected the export to match " + golden)
+	}
+
+	file := readParquet(t, buf.Bytes())
+	if file.rows != 3 {
+		t.Errorf("expected 3 rows, got %d", file.rows)
+	}
+
+	schema := []struct {
+		name                                                      string
+		physical, length, repetition, converted, scale, precision int64
+	}{
+		{"asset_id", parquetInt64, 0, parquetOptional, parquetConvertedInt64, 0, 0},
+		{"available_balance", parquetFixedLenByteArray, parquetDecimalSize, parquetOptional,
+			parquetConvertedDecimal, parquetDecimalScale, parquetDecimalPrecision},
+	}
+	if len(file.schema) != len(schema) {
+		t.Fatalf("expected %d columns, got %d", len(schema), len(file.schema))
+	}
+	for i, e := range schema {
+		element := file.schema[i]
+		optional := func(id int16) int64 {
+			n, _ := element[id].(int64)
+			return n
+		}
+		if element[4] != e.name || element[1] != e.physical || optional(2) != e.length ||
+			element[3] != e.repetition || element[6] != e.converted ||
+			optional(7) != e.scale || optional(8) != e.precision {
+			t.Errorf("column %d: expected %+v, got %v", i, e, element)
+		}
+	}
+
+	expectedColumns := [][]interface{}{
+		{int64(1), int64(3), int64(5)},
+		{"1234.500000000000000000", nil, "-0.000000000000000001"},
+	}
+	for i, column := range expectedColumns {
+		for j, value := range column {
+			if file.columns[i][j] != value {
+				t.Errorf("column %d row %d: expected %v, got %v", i, j, value, file.columns[i][j])
+			}
+		}
+	}
+}
+
+func TestExportParquetStrings(t *testing.T) {
+	orders := []*Order{
+		{ID: 7, Type: "MARKET_BUY", PrimaryAsset: "AUD", SecondaryAsset: "BTC", Total: 12.5},
+	}
+	var buf bytes.Buffer
+	if err := ExportOrders(&buf, Parquet, orders); err != nil {
+		t.Fatal(err)
+	}
+
+	file := readParquet(t, buf.Bytes())
+	columns := (&Order{}).ExportColumns()
+	for i, column := range columns {
+		if file.schema[i][4] != column.Name {
+			t.Errorf("expected column %d to be %s, got %v", i, column.Name, file.schema[i][4])
+		}
+	}
+	if file.columns[0][0] != int64(7) || file.columns[2][0] != "MARKET_BUY" ||
+		file.columns[1][0] != "" || file.columns[10][0] != "12.500000000000000000" {
+		t.Errorf("unexpected values %v", file.columns)
+	}
+}
+
+func TestExportParquetEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportBalances(&buf, Parquet, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	file := readParquet(t, buf.Bytes())
+	if file.rows != 0 || len(file.schema) != 0 {
+		t.Errorf("expected an empty file, got %d rows and %d columns", file.rows, len(file.schema))
+	}
+}
+
+func TestParquetDecimal(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"0", true},
+		{"0.0000000000000000005", true},
+		{"99999999999999999999.999999999999999999", true},
+		{"100000000000000000000", false},
+		{"1e3", true},
+		{"abc", false},
+	}
+	for _, test := range tests {
+		if _, err := parquetDecimal(test.value); (err == nil) != test.ok {
+			t.Errorf("%s: expected ok %t, got %v", test.value, test.ok, err)
+		}
+	}
+}
+
+func TestExporterMixed(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewExporter(&buf, CSV)
+	if err := e.Write(&AccountBalance{AssetID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Write(&OCHLVT{}); err != errExportMixed {
+		t.Errorf("expected %v, got %v", errExportMixed, err)
+	}
+
+	// a bad value must not leave the parquet columns with different lengths
+	e = NewExporter(&buf, Parquet)
+	if err := e.Write(&AccountBalance{AssetID: 1, AvailableBalance: "lots"}); err == nil {
+		t.Error("expected an invalid decimal to fail")
+	}
+	if len(e.parquet.columns[0].defined) != 0 {
+		t.Error("expected the invalid row not to be buffered")
+	}
+}
+
+func TestExporterNil(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewExporter(&buf, CSV)
+	if err := e.Write(nil); err != errExportNil {
+		t.Errorf("expected %v, got %v", errExportNil, err)
+	}
+	if err := e.Write((*OCHLVT)(nil)); err != errExportNil {
+		t.Errorf("expected %v for a nil pointer, got %v", errExportNil, err)
+	}
+}
+
+func exportTestBars() []*OCHLVT {
+	return []*OCHLVT{
+		{Time: SwyftxTime{time.Date(2021, time.March, 1, 10, 30, 0, 0, time.FixedZone("AEDT",
+			11*60*60))}, Open: "50000.12345678901234567", High: "51000", Low: "49000.5",
+			Close: "0.00000001", Volume: 12},
+		// missing prices are empty rather than zero
+		{Open: "", High: "", Low: "", Close: "1e3"},
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportBars(&buf, CSV, exportTestBars()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "time,open,high,low,close,volume\n" +
+		"2021-02-28T23:30:00Z,50000.12345678901234567,51000,49000.5,0.00000001,12\n" +
+		",,,,1e3,0\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// nothing is written without a record to take the header from
+	buf.Reset()
+	if err := ExportBars(&buf, CSV, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestExportJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportBars(&buf, JSONLines, exportTestBars()); err != nil {
+		t.Fatal(err)
+	}
+
+	// numbers are written exactly as swyftx returned them and empty numbers are null
+	expected := `{"time":"2021-02-28T23:30:00Z","open":50000.12345678901234567,"high":51000,` +
+		`"low":49000.5,"close":0.00000001,"volume":12}` + "\n" +
+		`{"time":"","open":null,"high":null,"low":null,"close":1e3,"volume":0}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	orders := []*Order{{ID: 7, Type: OrderTypeLimitBuy, PrimaryAsset: "AUD",
+		SecondaryAsset: "BTC", QuantityAsset: "BTC", Quantity: 2, Trigger: 100, Total: 0.1,
+		Price: 100, Status: `"quoted"`}}
+	buf.Reset()
+	if err := ExportOrders(&buf, JSONLines, orders); err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"id":7,"created_time":"","order_type":"3","primary_asset":"AUD",` +
+		`"secondary_asset":"BTC","quantity_asset":"BTC","quantity":2,"trigger":100,` +
+		`"status":"\"quoted\"","amount":0,"total":0.1,"price":100}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// a value that isn't a number must not produce invalid JSON
+	buf.Reset()
+	err := ExportBalances(&buf, JSONLines, []*AccountBalance{{AssetID: 1,
+		AvailableBalance: "1, \"injected\": 2"}})
+	if err == nil {
+		t.Errorf("expected an error, got %q", buf.String())
+	}
+}
@@ -12,8 +12,28 @@ func (c *Client) Funds(addressId int) *FundsService {
 	return &FundsService{service{c}, addressId}
 }
 
-// Withdraw funds from an account into a specified asset
+// Withdraw funds from an account into a specified asset. If the client has a withdrawal
+// policy the withdrawal must pass it first
 func (fs *FundsService) Withdraw(asset int, amount float32) error {
+	if err := fs.client.killSwitch.check(); err != nil {
+		return err
+	}
+	record, err := fs.client.withdrawal.check(fs.client, fs.id, asset, float64(amount))
+	if err != nil {
+		return err
+	}
+
+	return fs.withdraw(asset, amount, record)
+}
+
+// withdraw will make a withdrawal that has already been checked by the withdrawal policy,
+// committing its reservation if it is made and releasing it if it isn't
+func (fs *FundsService) withdraw(asset int, amount float32, record *withdrawalRecord) error {
+	if err := fs.client.killSwitch.check(); err != nil {
+		fs.client.withdrawal.release(record)
+		return err
+	}
+
 	var body struct {
 		Quantity  float32 `json:"quantity"`
 		AddressID int     `json:"address_id"`
@@ -22,8 +42,10 @@ func (fs *FundsService) Withdraw(asset int, amount float32) error {
 	body.Quantity = amount
 
 	if err := fs.client.Post(buildString("funds/withdraw/", strconv.Itoa(asset)), &body, nil); err != nil {
+		fs.client.withdrawal.release(record)
 		return err
 	}
+	fs.client.withdrawal.commit(record)
 
 	return nil
 }
@@ -1,12 +1,93 @@
 package goswyftx
 
-import "strconv"
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPageSize is the page size used when a query or filter doesn't set one
+const defaultPageSize = 100
 
 type HistoryService struct {
 	service
 	assetId int
 }
 
+// HistoryActionType is the type of history event to query
+type HistoryActionType string
+
+// History action types
+const (
+	HistoryAll      HistoryActionType = "all"
+	HistoryDeposit  HistoryActionType = "deposit"
+	HistoryWithdraw HistoryActionType = "withdraw"
+	HistoryBuy      HistoryActionType = "buy"
+	HistorySell     HistoryActionType = "sell"
+)
+
+// HistorySortOrder is the order history events are returned in
+type HistorySortOrder string
+
+// History sort orders
+const (
+	HistoryNewestFirst HistorySortOrder = "DESC"
+	HistoryOldestFirst HistorySortOrder = "ASC"
+)
+
+// HistoryQuery filters and pages history events, zero values are not filtered on
+type HistoryQuery struct {
+	ActionType HistoryActionType
+	From       time.Time
+	To         time.Time
+	Status     string
+	// PageSize is the number of events fetched in each request, defaults to 100
+	PageSize int
+	Sort     HistorySortOrder
+}
+
+// HistoryStatus is what a deposit, withdrawal or transaction status means for its funds
+type HistoryStatus int
+
+// History statuses
+const (
+	// HistoryStatusUnknown is a status that isn't recognised, it shouldn't be treated as
+	// finished either way
+	HistoryStatusUnknown HistoryStatus = iota
+	HistoryStatusPending
+	HistoryStatusCompleted
+	HistoryStatusFailed
+)
+
+// historyStatuses are the lower case history statuses that are recognised
+var historyStatuses = map[string]HistoryStatus{
+	"pending":               HistoryStatusPending,
+	"processing":            HistoryStatusPending,
+	"unverified":            HistoryStatusPending,
+	"awaiting verification": HistoryStatusPending,
+	"pending verification":  HistoryStatusPending,
+	"completed":             HistoryStatusCompleted,
+	"complete":              HistoryStatusCompleted,
+	"success":               HistoryStatusCompleted,
+	"successful":            HistoryStatusCompleted,
+	"failed":                HistoryStatusFailed,
+	"failure":               HistoryStatusFailed,
+	"cancelled":             HistoryStatusFailed,
+	"canceled":              HistoryStatusFailed,
+	"system cancelled":      HistoryStatusFailed,
+	"rejected":              HistoryStatusFailed,
+	"expired":               HistoryStatusFailed,
+}
+
+// ClassifyHistoryStatus will return what a history status means, ignoring case and
+// surrounding space. Statuses are matched exactly so one like "incomplete" isn't mistaken for
+// completed
+func ClassifyHistoryStatus(status string) HistoryStatus {
+	return historyStatuses[strings.ToLower(strings.TrimSpace(status))]
+}
+
 type CurrencyHistory struct {
 	ID        int        `json:"id,omitempty"`
 	Time      SwyftxTime `json:"time,omitempty"`
@@ -29,32 +110,293 @@ func (c *Client) History(asset int) *HistoryService {
 }
 
 // Withdraw events for an asset
-func (hs *HistoryService) Withdraw() (*CurrencyHistory, error) {
-	return hs.currency("withdraw")
+func (hs *HistoryService) Withdraw() ([]*CurrencyHistory, error) {
+	return hs.currency(HistoryWithdraw, nil)
 }
 
 // Deposit events for an asset
-func (hs *HistoryService) Deposit() (*CurrencyHistory, error) {
-	return hs.currency("deposit")
+func (hs *HistoryService) Deposit() ([]*CurrencyHistory, error) {
+	return hs.currency(HistoryDeposit, nil)
 }
 
-func (hs *HistoryService) currency(actionType string) (*CurrencyHistory, error) {
-	var histCurrency CurrencyHistory
-	if err := hs.client.Get(buildString("history/", actionType, "/", strconv.Itoa(hs.assetId)),
-		&histCurrency); err != nil {
+func (hs *HistoryService) currency(actionType HistoryActionType,
+	params url.Values) ([]*CurrencyHistory, error) {
+	var histCurrency []*CurrencyHistory
+	if err := hs.client.Get(hs.path(actionType, params), &histCurrency); err != nil {
 		return nil, err
 	}
 
-	return &histCurrency, nil
+	return histCurrency, nil
 }
 
 // All trades, withdrawals and deposits events for an asset
-func (hs *HistoryService) All(actionType string) ([]*TransactionHistory, error) {
+func (hs *HistoryService) All(actionType HistoryActionType) ([]*TransactionHistory, error) {
+	return hs.transactions(actionType, nil)
+}
+
+func (hs *HistoryService) transactions(actionType HistoryActionType,
+	params url.Values) ([]*TransactionHistory, error) {
 	var transHist []*TransactionHistory
-	if err := hs.client.Get(buildString("history/", actionType, "/", strconv.Itoa(hs.assetId)),
-		&transHist); err != nil {
+	if err := hs.client.Get(hs.path(actionType, params), &transHist); err != nil {
 		return nil, err
 	}
 
 	return transHist, nil
 }
+
+func (hs *HistoryService) path(actionType HistoryActionType, params url.Values) string {
+	path := buildString("history/", string(actionType), "/", strconv.Itoa(hs.assetId))
+	if len(params) > 0 {
+		path = buildString(path, "?", params.Encode())
+	}
+
+	return path
+}
+
+func (q *HistoryQuery) pageSize() int {
+	if q.PageSize <= 0 {
+		return defaultPageSize
+	}
+
+	return q.PageSize
+}
+
+func (q *HistoryQuery) params(page int) url.Values {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(q.pageSize()))
+	params.Set("page", strconv.Itoa(page))
+	if q.Sort != "" {
+		params.Set("sortDirection", string(q.Sort))
+	}
+	if !q.From.IsZero() {
+		params.Set("startDate", strconv.FormatInt(q.From.UnixNano()/int64(time.Millisecond), 10))
+	}
+	if !q.To.IsZero() {
+		params.Set("endDate", strconv.FormatInt(q.To.UnixNano()/int64(time.Millisecond), 10))
+	}
+	if q.Status != "" {
+		params.Set("status", q.Status)
+	}
+
+	return params
+}
+
+// matches the query's time range and status, these are also checked locally in case the api
+// ignores them
+func (q *HistoryQuery) matches(t time.Time, status string) bool {
+	if !q.From.IsZero() && t.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && t.After(q.To) {
+		return false
+	}
+	if q.Status != "" && !strings.EqualFold(q.Status, status) {
+		return false
+	}
+
+	return true
+}
+
+// pager walks pages of results until a short page is returned. Pages are also stopped once one
+// adds nothing new, in case the api ignores the page and keeps returning the same full page.
+// fetch returns the number of results on the page and should only keep the results that are
+// unseen, or every result of a page that isn't a repeat of the one before it
+type pager struct {
+	page     int
+	done     bool
+	err      error
+	seen     map[string]bool
+	lastPage string
+	added    int
+	fetch    func(page int) (int, error)
+}
+
+// nextPage will fetch the next page, it returns false once every page has been fetched or an
+// error occurred
+func (p *pager) nextPage(pageSize int) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+
+	p.page++
+	added := p.added
+	n, err := p.fetch(p.page)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	if n < pageSize || p.added == added {
+		p.done = true
+	}
+
+	return p.added > added
+}
+
+// unseen reports whether a result with key hasn't been returned by an earlier page
+func (p *pager) unseen(key string) bool {
+	if p.seen == nil {
+		p.seen = make(map[string]bool)
+	}
+	if p.seen[key] {
+		return false
+	}
+	p.seen[key] = true
+	p.added++
+
+	return true
+}
+
+// repeated reports whether a page with key is the same as the page before it. The results of
+// a page that isn't repeated are all counted as added
+func (p *pager) repeated(key string, results int) bool {
+	if p.page > 1 && key == p.lastPage {
+		return true
+	}
+	p.lastPage = key
+	p.added += results
+
+	return false
+}
+
+// HistoryIterator walks transaction history a page at a time
+type HistoryIterator struct {
+	pager   pager
+	query   HistoryQuery
+	buf     []*TransactionHistory
+	current *TransactionHistory
+}
+
+// Iterate will return an iterator over the transaction history matching query. An empty
+// action type queries every action type
+func (hs *HistoryService) Iterate(query HistoryQuery) *HistoryIterator {
+	if query.ActionType == "" {
+		query.ActionType = HistoryAll
+	}
+
+	it := &HistoryIterator{query: query}
+	it.pager.fetch = func(page int) (int, error) {
+		transactions, err := hs.transactions(query.ActionType, query.params(page))
+		it.fill(transactions)
+		return len(transactions), err
+	}
+
+	return it
+}
+
+// fill the buffer with the transactions on a page. Transactions have no ID and their times are
+// only precise to a couple of minutes, so identical transactions close together can't be told
+// apart. Rather than dropping them only a page that repeats the page before it is skipped, a
+// transaction pushed onto the next page by a new one is returned twice
+func (it *HistoryIterator) fill(transactions []*TransactionHistory) {
+	it.buf = nil
+
+	var key strings.Builder
+	for _, t := range transactions {
+		if t != nil {
+			fmt.Fprintf(&key, "%d/%d/%s/%s/%g;", t.Asset, t.Updated.UnixNano(), t.ActionType,
+				t.Status, t.Amount)
+			it.buf = append(it.buf, t)
+		}
+	}
+	if it.pager.repeated(key.String(), len(it.buf)) {
+		it.buf = nil
+	}
+}
+
+// Next will move to the next transaction, it returns false when there are no more
+// transactions or an error occurred
+func (it *HistoryIterator) Next() bool {
+	for {
+		for len(it.buf) > 0 {
+			it.current, it.buf = it.buf[0], it.buf[1:]
+			if it.query.matches(it.current.Updated.Time, it.current.Status) {
+				return true
+			}
+		}
+		if !it.pager.nextPage(it.query.pageSize()) {
+			it.current = nil
+			return false
+		}
+	}
+}
+
+// Transaction is the current transaction
+func (it *HistoryIterator) Transaction() *TransactionHistory {
+	return it.current
+}
+
+// Err is the error that stopped the iterator, if any
+func (it *HistoryIterator) Err() error {
+	return it.pager.err
+}
+
+// CurrencyHistoryIterator walks deposit or withdrawal history a page at a time
+type CurrencyHistoryIterator struct {
+	pager   pager
+	query   HistoryQuery
+	buf     []*CurrencyHistory
+	current *CurrencyHistory
+}
+
+// IterateDeposits will return an iterator over the deposits matching query, the action type
+// of the query is ignored
+func (hs *HistoryService) IterateDeposits(query HistoryQuery) *CurrencyHistoryIterator {
+	return hs.iterateCurrency(HistoryDeposit, query)
+}
+
+// IterateWithdrawals will return an iterator over the withdrawals matching query, the action
+// type of the query is ignored
+func (hs *HistoryService) IterateWithdrawals(query HistoryQuery) *CurrencyHistoryIterator {
+	return hs.iterateCurrency(HistoryWithdraw, query)
+}
+
+func (hs *HistoryService) iterateCurrency(actionType HistoryActionType,
+	query HistoryQuery) *CurrencyHistoryIterator {
+	query.ActionType = actionType
+
+	it := &CurrencyHistoryIterator{query: query}
+	it.pager.fetch = func(page int) (int, error) {
+		history, err := hs.currency(actionType, query.params(page))
+		it.fill(history)
+		return len(history), err
+	}
+
+	return it
+}
+
+// fill the buffer with the events that haven't been seen
+func (it *CurrencyHistoryIterator) fill(history []*CurrencyHistory) {
+	it.buf = nil
+	for _, h := range history {
+		if h != nil && it.pager.unseen(strconv.Itoa(h.ID)) {
+			it.buf = append(it.buf, h)
+		}
+	}
+}
+
+// Next will move to the next event, it returns false when there are no more events or an
+// error occurred
+func (it *CurrencyHistoryIterator) Next() bool {
+	for {
+		for len(it.buf) > 0 {
+			it.current, it.buf = it.buf[0], it.buf[1:]
+			if it.query.matches(it.current.Time.Time, it.current.Status) {
+				return true
+			}
+		}
+		if !it.pager.nextPage(it.query.pageSize()) {
+			it.current = nil
+			return false
+		}
+	}
+}
+
+// History is the current deposit or withdrawal
+func (it *CurrencyHistoryIterator) History() *CurrencyHistory {
+	return it.current
+}
+
+// Err is the error that stopped the iterator, if any
+func (it *CurrencyHistoryIterator) Err() error {
+	return it.pager.err
+}
@@ -0,0 +1,125 @@
+package goswyftx
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCurrencyHistoryIteratorRepeatedPage(t *testing.T) {
+	it := &CurrencyHistoryIterator{query: HistoryQuery{PageSize: 2}}
+	var fetches int
+	// the api ignores the page and keeps returning the same full page
+	it.pager.fetch = func(page int) (int, error) {
+		fetches++
+		page1 := []*CurrencyHistory{{ID: 1}, {ID: 2}}
+		it.fill(page1)
+		return len(page1), nil
+	}
+
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.History().ID)
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("expected events 1 and 2, got %v", ids)
+	}
+	if fetches != 2 {
+		t.Errorf("expected to stop after the repeated page, fetched %d pages", fetches)
+	}
+}
+
+func TestCurrencyHistoryIteratorOverlappingPages(t *testing.T) {
+	it := &CurrencyHistoryIterator{query: HistoryQuery{PageSize: 2}}
+	pages := [][]*CurrencyHistory{
+		{{ID: 1}, {ID: 2}},
+		// a new event pushed event 2 onto the second page
+		{{ID: 2}, {ID: 3}},
+		{{ID: 4}},
+	}
+	it.pager.fetch = func(page int) (int, error) {
+		it.fill(pages[page-1])
+		return len(pages[page-1]), nil
+	}
+
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.History().ID)
+	}
+	if len(ids) != 4 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 || ids[3] != 4 {
+		t.Errorf("expected events 1 to 4 once each, got %v", ids)
+	}
+}
+
+func TestHistoryIteratorRepeatedPage(t *testing.T) {
+	it := &HistoryIterator{query: HistoryQuery{PageSize: 1}}
+	var fetches int
+	it.pager.fetch = func(page int) (int, error) {
+		fetches++
+		transactions := []*TransactionHistory{{Asset: 3, Amount: 1, ActionType: "Deposit"}}
+		it.fill(transactions)
+		return len(transactions), nil
+	}
+
+	var n int
+	for it.Next() {
+		n++
+	}
+	if n != 1 || fetches != 2 {
+		t.Errorf("expected 1 transaction from 2 pages, got %d from %d", n, fetches)
+	}
+}
+
+func TestHistoryIteratorIdenticalTransactions(t *testing.T) {
+	it := &HistoryIterator{query: HistoryQuery{PageSize: 2}}
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	buy := func(offset time.Duration) *TransactionHistory {
+		// times are parsed as float32 so buys seconds apart have the same time
+		seconds, _ := strconv.ParseFloat(strconv.FormatInt(start.Add(offset).Unix(), 10), 32)
+		return &TransactionHistory{Asset: 3, Amount: 1, ActionType: "Buy",
+			Updated: SwyftxTime{Time: time.Unix(int64(seconds), 0)}}
+	}
+	pages := [][]*TransactionHistory{
+		{buy(0), buy(5 * time.Second)},
+		{buy(10 * time.Second)},
+	}
+	it.pager.fetch = func(page int) (int, error) {
+		it.fill(pages[page-1])
+		return len(pages[page-1]), nil
+	}
+
+	var n int
+	for it.Next() {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("expected 3 transactions, got %d", n)
+	}
+}
+
+func TestClassifyHistoryStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   HistoryStatus
+	}{
+		{"Completed", HistoryStatusCompleted},
+		{" success ", HistoryStatusCompleted},
+		{"incomplete", HistoryStatusUnknown},
+		{"Pending", HistoryStatusPending},
+		{"Awaiting Verification", HistoryStatusPending},
+		{"verified", HistoryStatusUnknown},
+		{"Cancelled", HistoryStatusFailed},
+		{"System Cancelled", HistoryStatusFailed},
+		{"not failed", HistoryStatusUnknown},
+		{"", HistoryStatusUnknown},
+	}
+
+	for _, test := range tests {
+		if got := ClassifyHistoryStatus(test.status); got != test.want {
+			t.Errorf("%q: expected %d, got %d", test.status, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package goswyftx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// ErrKillSwitchTripped is returned by OrderService.Place and FundsService.Withdraw once the
+// client's kill switch has been tripped
+var ErrKillSwitchTripped = errors.New("kill switch tripped, trading is halted")
+
+// KillSwitchConfig sets when a kill switch trips automatically, zero values disable a check
+type KillSwitchConfig struct {
+	// MaxLoss is how far the portfolio value, in the account's default currency, can fall
+	// below its value at the first CheckLoss before it trips
+	MaxLoss float64
+	// MaxConsecutiveErrors is the number of requests in a row that can fail before it trips
+	MaxConsecutiveErrors int
+	// OnTrip is called once when the kill switch trips, along with any error from cancelling
+	// open orders
+	OnTrip func(reason string, err error)
+}
+
+// KillSwitch halts trading on a client. Once tripped every order placement and withdrawal is
+// refused with ErrKillSwitchTripped and all open orders are cancelled. It is shared by every
+// client derived from the one it was created with after it was created
+type KillSwitch struct {
+	client *Client
+	cfg    KillSwitchConfig
+	// cancelAll cancels every open order when the kill switch trips
+	cancelAll func() error
+
+	mu       sync.Mutex
+	tripped  bool
+	reason   string
+	errors   int
+	baseline float64
+	hasBase  bool
+}
+
+// NewKillSwitch will create a kill switch and attach it to c
+func NewKillSwitch(c *Client, cfg KillSwitchConfig) *KillSwitch {
+	ks := &KillSwitch{client: c, cfg: cfg}
+	ks.cancelAll = ks.cancelOpenOrders
+	c.killSwitch = ks
+
+	return ks
+}
+
+// Trip will halt trading and cancel every open order, it returns any error from cancelling
+// orders. Tripping a kill switch that has already tripped does nothing
+func (ks *KillSwitch) Trip(reason string) error {
+	ks.mu.Lock()
+	if ks.tripped {
+		ks.mu.Unlock()
+		return nil
+	}
+	ks.tripped = true
+	ks.reason = reason
+	ks.mu.Unlock()
+
+	err := ks.cancelAll()
+	if ks.cfg.OnTrip != nil {
+		ks.cfg.OnTrip(reason, err)
+	}
+
+	return err
+}
+
+func (ks *KillSwitch) cancelOpenOrders() error {
+	// cancelling isn't blocked by the kill switch, use a fresh context so a cancelled client
+	// context can't stop orders being cancelled
+	_, err := ks.client.WithContext(context.Background()).Order().CancelAll(context.Background(),
+		OrderFilter{}, 0)
+	if err != nil {
+		return fmt.Errorf("could not cancel open orders: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Tripped reports whether the kill switch has tripped and why
+func (ks *KillSwitch) Tripped() (bool, string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	return ks.tripped, ks.reason
+}
+
+// Reset will allow trading again, open orders that were cancelled are not restored
+func (ks *KillSwitch) Reset() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.tripped = false
+	ks.reason = ""
+	ks.errors = 0
+}
+
+// TripOnSignal will trip the kill switch when the process receives one of sigs, the
+// returned function stops listening for them
+func (ks *KillSwitch) TripOnSignal(sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			_ = ks.Trip(buildString("received signal ", sig.String()))
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// CheckLoss will value the portfolio and trip the kill switch if it has fallen by more than
+// the configured max loss. The first check records the value losses are measured from
+func (ks *KillSwitch) CheckLoss() error {
+	if ks.cfg.MaxLoss <= 0 {
+		return nil
+	}
+
+	snapshot, err := ks.client.Portfolio().Snapshot()
+	if err != nil {
+		return fmt.Errorf("could not value portfolio: %s", err.Error())
+	}
+
+	ks.mu.Lock()
+	if !ks.hasBase {
+		ks.baseline, ks.hasBase = snapshot.Total, true
+	}
+	loss := ks.baseline - snapshot.Total
+	ks.mu.Unlock()
+
+	if loss > ks.cfg.MaxLoss {
+		return ks.Trip(fmt.Sprintf("portfolio lost %.2f %s, more than the max loss of %.2f",
+			loss, snapshot.Currency, ks.cfg.MaxLoss))
+	}
+
+	return nil
+}
+
+// Monitor will check for losses every interval until ctx is done or the kill switch trips.
+// Errors valuing the portfolio count towards the consecutive error limit
+func (ks *KillSwitch) Monitor(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := ks.CheckLoss()
+		if tripped, _ := ks.Tripped(); tripped {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ks *KillSwitch) check() error {
+	if ks == nil {
+		return nil
+	}
+
+	if tripped, _ := ks.Tripped(); tripped {
+		return ErrKillSwitchTripped
+	}
+
+	return nil
+}
+
+// record the result of a request, tripping the kill switch after too many errors in a row
+func (ks *KillSwitch) record(err error) {
+	if ks == nil || ks.cfg.MaxConsecutiveErrors <= 0 {
+		return
+	}
+
+	ks.mu.Lock()
+	if err == nil {
+		ks.errors = 0
+		ks.mu.Unlock()
+		return
+	}
+	ks.errors++
+	trip := !ks.tripped && ks.errors >= ks.cfg.MaxConsecutiveErrors
+	count := ks.errors
+	ks.mu.Unlock()
+
+	if trip {
+		go ks.Trip(fmt.Sprintf("%d consecutive api errors, last: %s", count, err.Error()))
+	}
+}
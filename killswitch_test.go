@@ -0,0 +1,98 @@
+package goswyftx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKillSwitchTripAndReset(t *testing.T) {
+	var trips []string
+	ks := NewKillSwitch(&Client{}, KillSwitchConfig{OnTrip: func(reason string, err error) {
+		if err == nil || err.Error() != "cancel failed" {
+			t.Errorf("expected the cancel error, got %v", err)
+		}
+		trips = append(trips, reason)
+	}})
+	cancels := 0
+	ks.cancelAll = func() error {
+		cancels++
+		return errors.New("cancel failed")
+	}
+
+	if err := ks.check(); err != nil {
+		t.Fatalf("expected a new kill switch not to be tripped, got %v", err)
+	}
+	if err := ks.Trip("manual"); err == nil {
+		t.Error("expected the cancel error to be returned")
+	}
+	if err := ks.Trip("again"); err != nil {
+		t.Errorf("expected tripping again to do nothing, got %v", err)
+	}
+	if cancels != 1 || len(trips) != 1 || trips[0] != "manual" {
+		t.Errorf("expected one trip and cancel, got %d cancels and trips %v", cancels, trips)
+	}
+	if tripped, reason := ks.Tripped(); !tripped || reason != "manual" {
+		t.Errorf("expected to be tripped by manual, got %t %q", tripped, reason)
+	}
+	if err := ks.client.killSwitch.check(); err != ErrKillSwitchTripped {
+		t.Errorf("expected %v, got %v", ErrKillSwitchTripped, err)
+	}
+
+	ks.Reset()
+	if tripped, reason := ks.Tripped(); tripped || reason != "" {
+		t.Errorf("expected a reset kill switch, got %t %q", tripped, reason)
+	}
+	if err := ks.check(); err != nil {
+		t.Errorf("expected trading to be allowed after a reset, got %v", err)
+	}
+	if err := ks.Trip("after reset"); err == nil || cancels != 2 {
+		t.Errorf("expected to trip again after a reset, got %v after %d cancels", err, cancels)
+	}
+}
+
+func TestKillSwitchConsecutiveErrors(t *testing.T) {
+	tripped := make(chan string, 1)
+	ks := NewKillSwitch(&Client{}, KillSwitchConfig{
+		MaxConsecutiveErrors: 3,
+		OnTrip: func(reason string, err error) {
+			tripped <- reason
+		},
+	})
+	ks.cancelAll = func() error { return nil }
+
+	failure := errors.New("timeout")
+	ks.record(failure)
+	ks.record(failure)
+	// a success starts the count again
+	ks.record(nil)
+	ks.record(failure)
+	ks.record(failure)
+	if on, _ := ks.Tripped(); on {
+		t.Fatal("expected the kill switch not to trip before 3 errors in a row")
+	}
+
+	ks.record(failure)
+	select {
+	case reason := <-tripped:
+		if reason != "3 consecutive api errors, last: timeout" {
+			t.Errorf("unexpected reason %q", reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the kill switch to trip")
+	}
+
+	var nilSwitch *KillSwitch
+	nilSwitch.record(failure)
+	if err := nilSwitch.check(); err != nil {
+		t.Errorf("expected a nil kill switch to allow trading, got %v", err)
+	}
+}
+
+func TestKillSwitchMonitorRejectsInterval(t *testing.T) {
+	ks := NewKillSwitch(&Client{}, KillSwitchConfig{})
+	if err := ks.Monitor(context.Background(), 0); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
@@ -0,0 +1,305 @@
+package goswyftx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LedgerKind is the kind of event a ledger entry records
+type LedgerKind string
+
+// Ledger entry kinds
+const (
+	LedgerDeposit    LedgerKind = "deposit"
+	LedgerWithdrawal LedgerKind = "withdrawal"
+	LedgerBuy        LedgerKind = "buy"
+	LedgerSell       LedgerKind = "sell"
+)
+
+// LedgerEntry is a single change to the balance of an asset
+type LedgerEntry struct {
+	Time  time.Time
+	Asset string
+	// Quantity is positive when the balance increased and negative when it decreased
+	Quantity float64
+	// CounterAsset and CounterQuantity are the other side of a trade, they are empty for
+	// deposits and withdrawals
+	CounterAsset    string
+	CounterQuantity float64
+	// Fee of a trade in the quote asset, it is only set on the quote asset's entry so fees
+	// aren't counted twice. The fee reported by the order is used, orders that don't report
+	// one have it estimated at DefaultFeeRate of the trade's value
+	Fee float64
+	// Reference is the ID of the order, deposit or withdrawal the entry came from
+	Reference string
+	Kind      LedgerKind
+	Status    string
+}
+
+// LedgerService normalises deposits, withdrawals and trades into a single ledger
+type LedgerService service
+
+// Ledger will return a ledger service that can build a ledger of every balance change
+func (c *Client) Ledger() *LedgerService {
+	return (*LedgerService)(&service{c})
+}
+
+// Entries will fetch the deposits, withdrawals, transactions and orders of every asset in the
+// registry, or only the given asset codes, and return them as ledger entries sorted by time
+// with duplicates removed
+func (ls *LedgerService) Entries(assetCodes ...string) ([]*LedgerEntry, error) {
+	registry, err := ls.client.Market().Registry()
+	if err != nil {
+		return nil, fmt.Errorf("could not get assets: %s", err.Error())
+	}
+
+	assets := registry.Assets()
+	if len(assetCodes) > 0 {
+		assets = make([]*MarketAsset, 0, len(assetCodes))
+		for _, code := range assetCodes {
+			asset, ok := registry.ByCode(code)
+			if !ok {
+				return nil, fmt.Errorf("unknown asset: %s", code)
+			}
+			assets = append(assets, asset)
+		}
+	}
+
+	var entries []*LedgerEntry
+	for _, asset := range assets {
+		assetEntries, err := ls.assetEntries(registry, asset)
+		if err != nil {
+			return nil, fmt.Errorf("could not get ledger for %s: %s", asset.Code, err.Error())
+		}
+		entries = append(entries, assetEntries...)
+	}
+
+	return dedupeLedger(entries), nil
+}
+
+func (ls *LedgerService) assetEntries(registry *AssetRegistry, asset *MarketAsset) ([]*LedgerEntry,
+	error) {
+	var entries []*LedgerEntry
+
+	history := ls.client.History(asset.ID)
+	var err error
+	deposits := history.IterateDeposits(HistoryQuery{})
+	for deposits.Next() {
+		if entries, err = appendCurrencyEntry(entries, asset.Code, LedgerDeposit,
+			deposits.History()); err != nil {
+			return nil, err
+		}
+	}
+	if err = deposits.Err(); err != nil {
+		return nil, err
+	}
+
+	withdrawals := history.IterateWithdrawals(HistoryQuery{})
+	for withdrawals.Next() {
+		if entries, err = appendCurrencyEntry(entries, asset.Code, LedgerWithdrawal,
+			withdrawals.History()); err != nil {
+			return nil, err
+		}
+	}
+	if err = withdrawals.Err(); err != nil {
+		return nil, err
+	}
+
+	orders, err := ls.client.Order().List(asset.Code)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, OrderLedgerEntries(orders)...)
+
+	var transactions []*TransactionHistory
+	it := history.Iterate(HistoryQuery{ActionType: HistoryAll})
+	for it.Next() {
+		transactions = append(transactions, it.Transaction())
+	}
+	if err = it.Err(); err != nil {
+		return nil, err
+	}
+	entries = append(entries, TransactionLedgerEntries(registry, transactions)...)
+
+	return entries, nil
+}
+
+func appendCurrencyEntry(entries []*LedgerEntry, asset string, kind LedgerKind,
+	history *CurrencyHistory) ([]*LedgerEntry, error) {
+	if history == nil || history.ID == 0 {
+		return entries, nil
+	}
+
+	quantity, err := parseFloat(history.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse quantity of %s %d: %s", kind, history.ID,
+			err.Error())
+	}
+	if kind == LedgerWithdrawal {
+		quantity = -math.Abs(quantity)
+	}
+
+	return append(entries, &LedgerEntry{
+		Time:      history.Time.Time,
+		Asset:     strings.ToUpper(asset),
+		Quantity:  quantity,
+		Reference: strconv.Itoa(history.ID),
+		Kind:      kind,
+		Status:    history.Status,
+	}), nil
+}
+
+// OrderLedgerEntries will convert completed orders into a pair of ledger entries, one for the
+// secondary asset and one for the primary asset it was traded against
+func OrderLedgerEntries(orders []*Order) []*LedgerEntry {
+	var entries []*LedgerEntry
+	for _, trade := range TradesFromOrders(orders) {
+		kind, counterKind := LedgerBuy, LedgerSell
+		if trade.Quantity < 0 {
+			kind, counterKind = LedgerSell, LedgerBuy
+		}
+		counterQuantity := -trade.Quantity * trade.Price
+		fee := trade.Fee
+		if fee == 0 {
+			fee = math.Abs(counterQuantity) * DefaultFeeRate
+		}
+
+		entries = append(entries, &LedgerEntry{
+			Time:            trade.Time,
+			Asset:           strings.ToUpper(trade.Asset),
+			Quantity:        trade.Quantity,
+			CounterAsset:    strings.ToUpper(trade.Quote),
+			CounterQuantity: counterQuantity,
+			Reference:       trade.Reference,
+			Kind:            kind,
+			Status:          OrderStatusCompleted,
+		}, &LedgerEntry{
+			Time:            trade.Time,
+			Asset:           strings.ToUpper(trade.Quote),
+			Quantity:        counterQuantity,
+			CounterAsset:    strings.ToUpper(trade.Asset),
+			CounterQuantity: trade.Quantity,
+			Fee:             fee,
+			Reference:       trade.Reference,
+			Kind:            counterKind,
+			Status:          OrderStatusCompleted,
+		})
+	}
+
+	return entries
+}
+
+// TransactionLedgerEntries will convert transaction history into ledger entries, transactions
+// with an unknown asset or action type are skipped
+func TransactionLedgerEntries(registry *AssetRegistry, transactions []*TransactionHistory) []*LedgerEntry {
+	var entries []*LedgerEntry
+	for _, transaction := range transactions {
+		asset, ok := registry.ByID(transaction.Asset)
+		if !ok {
+			continue
+		}
+
+		quantity := math.Abs(float64(transaction.Amount))
+		var kind LedgerKind
+		switch strings.ToLower(transaction.ActionType) {
+		case "deposit":
+			kind = LedgerDeposit
+		case "withdraw", "withdrawal":
+			kind, quantity = LedgerWithdrawal, -quantity
+		case "buy":
+			kind = LedgerBuy
+		case "sell":
+			kind, quantity = LedgerSell, -quantity
+		default:
+			continue
+		}
+
+		entries = append(entries, &LedgerEntry{
+			Time:     transaction.Updated.Time,
+			Asset:    strings.ToUpper(asset.Code),
+			Quantity: quantity,
+			Kind:     kind,
+			Status:   transaction.Status,
+		})
+	}
+
+	return entries
+}
+
+const (
+	// ledgerMatchWindow is how far apart two entries without a reference can be and still be
+	// treated as the same event. Times are parsed as float32 seconds which are only precise to
+	// 128 seconds, so two times of the same event can be up to that far apart once parsed
+	ledgerMatchWindow = 5 * time.Minute
+	// ledgerMatchTolerance is the relative difference allowed between the quantities of the
+	// same event, transaction history amounts are float32 so they only agree to about 1e-7
+	ledgerMatchTolerance = 1e-6
+)
+
+// dedupeLedger will sort entries by time and remove duplicates. Entries with a reference are
+// the same if their kind, asset and reference match. Entries without one, such as those from
+// transaction history, are dropped if an entry of the same kind, asset and quantity is within
+// ledgerMatchWindow of them. Each entry can only be the duplicate of one entry without a
+// reference
+func dedupeLedger(entries []*LedgerEntry) []*LedgerEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	seen := make(map[string]bool)
+	var referenced []*LedgerEntry
+	for _, entry := range entries {
+		if isEmptyStr(entry.Reference) {
+			continue
+		}
+		key := buildString(string(entry.Kind), "/", entry.Asset, "/", entry.Reference)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		referenced = append(referenced, entry)
+	}
+
+	deduped := make([]*LedgerEntry, 0, len(entries))
+	deduped = append(deduped, referenced...)
+	matched := make(map[*LedgerEntry]bool)
+	for _, entry := range entries {
+		if !isEmptyStr(entry.Reference) {
+			continue
+		}
+
+		duplicate := false
+		for _, other := range deduped {
+			if !matched[other] && other.Kind == entry.Kind && other.Asset == entry.Asset &&
+				math.Abs(other.Quantity-entry.Quantity) <= ledgerMatchTolerance*math.Max(1,
+					math.Abs(entry.Quantity)) &&
+				absDuration(other.Time.Sub(entry.Time)) <= ledgerMatchWindow {
+				matched[other], duplicate = true, true
+				break
+			}
+		}
+		if !duplicate {
+			deduped = append(deduped, entry)
+		}
+	}
+
+	sort.SliceStable(deduped, func(i, j int) bool {
+		if deduped[i].Time.Equal(deduped[j].Time) {
+			return deduped[i].Reference < deduped[j].Reference
+		}
+		return deduped[i].Time.Before(deduped[j].Time)
+	})
+
+	return deduped
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
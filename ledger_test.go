@@ -0,0 +1,103 @@
+package goswyftx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDedupeLedger(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	registry := NewAssetRegistry([]*MarketAsset{{ID: 1, Code: "AUD"}, {ID: 3, Code: "BTC"}})
+
+	var entries []*LedgerEntry
+	var err error
+	for _, h := range []*CurrencyHistory{
+		{ID: 10, Time: SwyftxTime{start}, Quantity: "0.123456789", Status: "Completed"},
+		// the same deposit from a second page
+		{ID: 10, Time: SwyftxTime{start}, Quantity: "0.123456789", Status: "Completed"},
+	} {
+		if entries, err = appendCurrencyEntry(entries, "btc", LedgerDeposit, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries = append(entries, OrderLedgerEntries([]*Order{{
+		ID:             20,
+		Type:           OrderTypeMarketBuy,
+		PrimaryAsset:   "AUD",
+		SecondaryAsset: "BTC",
+		Amount:         2,
+		Price:          100,
+		Status:         OrderStatusCompleted,
+		CreateTime:     SwyftxTime{start.Add(time.Hour)},
+		FeeAmount:      "1.5",
+	}})...)
+	entries = append(entries, TransactionLedgerEntries(registry, []*TransactionHistory{
+		// the float32 amount of the deposit above, with a float32 time that has been rounded
+		// to the next 128 seconds
+		{Asset: 3, Amount: float32(0.123456789), ActionType: "Deposit",
+			Updated: SwyftxTime{start.Add(128 * time.Second)}},
+		// the buy above
+		{Asset: 3, Amount: 2, ActionType: "Buy", Updated: SwyftxTime{start.Add(time.Hour)}},
+		// a second buy of the same amount isn't the same trade
+		{Asset: 3, Amount: 2, ActionType: "Buy",
+			Updated: SwyftxTime{start.Add(time.Hour + time.Second)}},
+		// too long after the deposit to be the same one
+		{Asset: 3, Amount: float32(0.123456789), ActionType: "Deposit",
+			Updated: SwyftxTime{start.Add(time.Hour)}},
+		{Asset: 99, Amount: 1, ActionType: "Deposit", Updated: SwyftxTime{start}},
+	})...)
+
+	deduped := dedupeLedger(entries)
+	expected := []struct {
+		kind      LedgerKind
+		asset     string
+		quantity  float64
+		reference string
+	}{
+		{LedgerDeposit, "BTC", 0.123456789, "10"},
+		// entries at the same time are ordered by reference
+		{LedgerDeposit, "BTC", 0.123456789, ""},
+		{LedgerBuy, "BTC", 2, "20"},
+		{LedgerSell, "AUD", -200, "20"},
+		{LedgerBuy, "BTC", 2, ""},
+	}
+	if len(deduped) != len(expected) {
+		for _, entry := range deduped {
+			t.Logf("%+v", entry)
+		}
+		t.Fatalf("expected %d entries, got %d", len(expected), len(deduped))
+	}
+	for i, e := range expected {
+		entry := deduped[i]
+		if entry.Kind != e.kind || entry.Asset != e.asset || entry.Reference != e.reference ||
+			math.Abs(entry.Quantity-e.quantity) > 1e-6 {
+			t.Errorf("entry %d: expected %s %f %s ref %q, got %s %f %s ref %q", i, e.kind,
+				e.quantity, e.asset, e.reference, entry.Kind, entry.Quantity, entry.Asset,
+				entry.Reference)
+		}
+	}
+
+	if fee := deduped[3].Fee; fee != 1.5 {
+		t.Errorf("expected the AUD leg to have the order's fee of 1.5, got %f", fee)
+	}
+	if deduped[2].Fee != 0 {
+		t.Errorf("expected the BTC leg to have no fee, got %f", deduped[2].Fee)
+	}
+
+	// orders that don't report a fee have it estimated
+	entries = OrderLedgerEntries([]*Order{{ID: 21, Type: OrderTypeMarketSell, PrimaryAsset: "AUD",
+		SecondaryAsset: "BTC", Amount: 2, Price: 100, Status: OrderStatusCompleted}})
+	if fee := entries[1].Fee; math.Abs(fee-200*DefaultFeeRate) > 1e-9 {
+		t.Errorf("expected the AUD leg to have an estimated fee of %f, got %f",
+			200*DefaultFeeRate, fee)
+	}
+}
+
+func TestAppendCurrencyEntryInvalidQuantity(t *testing.T) {
+	_, err := appendCurrencyEntry(nil, "BTC", LedgerWithdrawal, &CurrencyHistory{ID: 1,
+		Quantity: "lots"})
+	if err == nil {
+		t.Error("expected an invalid quantity to fail")
+	}
+}
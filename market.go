@@ -25,6 +25,13 @@ type MarketAsset struct {
 	Secondary             bool    `json:"secondary,omitempty"`
 }
 
+// Increment is the minimum order increment. It is a float32 so it goes through its shortest
+// decimal, otherwise 0.01 would be widened to 0.009999999776
+func (a *MarketAsset) Increment() float64 {
+	increment, _ := strconv.ParseFloat(formatFloat32(a.MinimumOrderIncrement), 64)
+	return increment
+}
+
 type MarketBasicInfo struct {
 	Name      string  `json:"name,omitempty"`
 	AltName   string  `json:"altName,omitempty"`
@@ -113,3 +120,22 @@ func (ms *MarketService) DetailedInfo(assetCode string) ([]*MarketDetailedInfo,
 
 	return detailedInfo, nil
 }
+
+// Rates will get the live rates of every asset against a base asset, keyed by asset ID
+func (ms *MarketService) Rates(baseAsset int) (map[int]*MarketRate, error) {
+	var rates map[string]*MarketRate
+	if err := ms.client.Get(buildString("live-rates/", strconv.Itoa(baseAsset)), &rates); err != nil {
+		return nil, err
+	}
+
+	marketRates := make(map[int]*MarketRate, len(rates))
+	for id, rate := range rates {
+		assetID, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		marketRates[assetID] = rate
+	}
+
+	return marketRates, nil
+}
@@ -1,9 +1,59 @@
 package goswyftx
 
-import "strconv"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type OrderService service
 
+// Order types used by OrderPlace.OrderType and Order.Type
+const (
+	OrderTypeMarketBuy     = "1"
+	OrderTypeMarketSell    = "2"
+	OrderTypeLimitBuy      = "3"
+	OrderTypeLimitSell     = "4"
+	OrderTypeStopLimitBuy  = "5"
+	OrderTypeStopLimitSell = "6"
+)
+
+// Order statuses used by Order.Status
+const (
+	OrderStatusOpen            = "1"
+	OrderStatusPending         = "2"
+	OrderStatusCompleted       = "3"
+	OrderStatusCancelled       = "4"
+	OrderStatusFailed          = "5"
+	OrderStatusExpired         = "6"
+	OrderStatusSystemCancelled = "7"
+)
+
+// OrderSide is whether an order buys or sells the secondary asset
+type OrderSide int
+
+// Order sides, OrderSideAny matches both
+const (
+	OrderSideAny OrderSide = iota
+	OrderSideBuy
+	OrderSideSell
+)
+
+// IsBuyOrder will report whether an order type buys the secondary asset
+func IsBuyOrder(orderType string) bool {
+	switch orderType {
+	case OrderTypeMarketBuy, OrderTypeLimitBuy, OrderTypeStopLimitBuy:
+		return true
+	}
+
+	return false
+}
+
 type OrderExchangeRate struct {
 	Mid   string `json:"mid,omitempty"`
 	Price string `json:"price,omitempty"`
@@ -31,6 +81,19 @@ type Order struct {
 	Price          int        `json:"price,omitempty"`
 	CreateTime     SwyftxTime `json:"created_time,omitempty"`
 	ID             int        `json:"id,omitempty"`
+	// FeeAmount is the fee charged in the primary asset, it isn't reported for every order
+	FeeAmount json.Number `json:"feeAmount,omitempty"`
+}
+
+// orderTrigger will convert a limit price to an order trigger. A trigger only holds a whole
+// number so a price that isn't a positive whole number is rejected rather than moved
+func orderTrigger(price float64) (int, error) {
+	if price < 1 || price != math.Trunc(price) || price > math.MaxInt32 {
+		return 0, fmt.Errorf("price %g can't be used as an order trigger, it must be a positive "+
+			"whole number", price)
+	}
+
+	return int(price), nil
 }
 
 // Order will return a order service that can interact with swyftx api
@@ -63,7 +126,22 @@ func (os *OrderService) PairExchangeRate(buy, sell string, amount int,
 }
 
 // Place will create an order from an OrderPlace, returns the order id
-func (os *OrderService) Place(order *OrderPlace) (int, error) {
+func (os *OrderService) Place(order *OrderPlace, opts ...PlaceOption) (int, error) {
+	if err := os.client.killSwitch.check(); err != nil {
+		return 0, err
+	}
+
+	var options placeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := os.guardSlippage(order, &options); err != nil {
+		return 0, err
+	}
+	if err := os.guardCost(order, &options); err != nil {
+		return 0, err
+	}
+
 	var orderID struct {
 		OrderID int `json:"orderId"`
 	}
@@ -92,3 +170,178 @@ func (os *OrderService) List(asset string) ([]*Order, error) {
 
 	return orders, nil
 }
+
+// OrderFilter filters and pages orders, zero values are not filtered on
+type OrderFilter struct {
+	// Statuses and Types the order must have one of
+	Statuses []string
+	Types    []string
+	// Primary and Secondary asset codes of the order, if Secondary is empty the orders of
+	// every asset are iterated
+	Primary   string
+	Secondary string
+	// Side restricts the orders to buys or sells
+	Side OrderSide
+	// From and To bound the order's created time
+	From time.Time
+	To   time.Time
+	// PageSize is the number of orders fetched in each request, defaults to 100
+	PageSize int
+}
+
+func (f *OrderFilter) pageSize() int {
+	if f.PageSize <= 0 {
+		return defaultPageSize
+	}
+
+	return f.PageSize
+}
+
+func (f *OrderFilter) matches(order *Order) bool {
+	if len(f.Statuses) > 0 && !containsString(f.Statuses, order.Status) {
+		return false
+	}
+	if len(f.Types) > 0 && !containsString(f.Types, order.Type) {
+		return false
+	}
+	if f.Side == OrderSideBuy && !IsBuyOrder(order.Type) ||
+		f.Side == OrderSideSell && IsBuyOrder(order.Type) {
+		return false
+	}
+	if f.Primary != "" && !strings.EqualFold(f.Primary, order.PrimaryAsset) {
+		return false
+	}
+	if f.Secondary != "" && !strings.EqualFold(f.Secondary, order.SecondaryAsset) {
+		return false
+	}
+	if !f.From.IsZero() && order.CreateTime.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && order.CreateTime.After(f.To) {
+		return false
+	}
+
+	return true
+}
+
+// OrderIterator walks orders a page at a time, across every asset if the filter doesn't name
+// one. An order is listed under both of its assets but is only returned once
+type OrderIterator struct {
+	os      *OrderService
+	ctx     context.Context
+	filter  OrderFilter
+	assets  []string
+	started bool
+	seen    map[int]bool
+	page    func(asset string, limit, page int) ([]*Order, error)
+	pager   *pager
+	buf     []*Order
+	current *Order
+	err     error
+}
+
+// Iterate will return an iterator over the orders matching filter. Orders are only fetched
+// as the iterator reaches them and requests are made with ctx
+func (os *OrderService) Iterate(ctx context.Context, filter OrderFilter) *OrderIterator {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	it := &OrderIterator{
+		os:     (*OrderService)(&service{os.client.WithContext(ctx)}),
+		ctx:    ctx,
+		filter: filter,
+		seen:   make(map[int]bool),
+	}
+	it.page = it.os.page
+
+	return it
+}
+
+// Next will move to the next order, it returns false when there are no more orders or an
+// error occurred
+func (it *OrderIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if !it.started {
+		it.started = true
+		if it.filter.Secondary != "" {
+			it.assets = []string{it.filter.Secondary}
+		} else {
+			registry, err := it.os.client.Market().Registry()
+			if err != nil {
+				it.err = fmt.Errorf("could not get assets: %s", err.Error())
+				return false
+			}
+			for _, asset := range registry.Assets() {
+				it.assets = append(it.assets, asset.Code)
+			}
+		}
+	}
+
+	for {
+		for len(it.buf) > 0 {
+			it.current, it.buf = it.buf[0], it.buf[1:]
+			if !it.seen[it.current.ID] && it.filter.matches(it.current) {
+				it.seen[it.current.ID] = true
+				return true
+			}
+		}
+		it.current = nil
+
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		if it.pager != nil && it.pager.nextPage(it.filter.pageSize()) {
+			continue
+		}
+		if it.pager != nil && it.pager.err != nil {
+			it.err = it.pager.err
+			return false
+		}
+
+		if len(it.assets) == 0 {
+			return false
+		}
+		asset := it.assets[0]
+		it.assets = it.assets[1:]
+		it.pager = &pager{}
+		it.pager.fetch = func(page int) (int, error) {
+			orders, err := it.page(asset, it.filter.pageSize(), page)
+			it.buf = nil
+			for _, order := range orders {
+				if order != nil && it.pager.unseen(strconv.Itoa(order.ID)) {
+					it.buf = append(it.buf, order)
+				}
+			}
+			return len(orders), err
+		}
+	}
+}
+
+// Order is the current order
+func (it *OrderIterator) Order() *Order {
+	return it.current
+}
+
+// Err is the error that stopped the iterator, if any
+func (it *OrderIterator) Err() error {
+	return it.err
+}
+
+func (os *OrderService) page(asset string, limit, page int) ([]*Order, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("page", strconv.Itoa(page))
+
+	var orders []*Order
+	if err := os.client.Get(buildString("orders/", asset, "?", params.Encode()), &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,87 @@
+package goswyftx
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestOrderIteratorDedupe(t *testing.T) {
+	// an AUD/BTC order is listed under both AUD and BTC
+	orders := map[string][]*Order{
+		"AUD": {{ID: 1, PrimaryAsset: "AUD", SecondaryAsset: "BTC"},
+			{ID: 2, PrimaryAsset: "AUD", SecondaryAsset: "ETH"}},
+		"BTC": {{ID: 1, PrimaryAsset: "AUD", SecondaryAsset: "BTC"}},
+		"ETH": {{ID: 2, PrimaryAsset: "AUD", SecondaryAsset: "ETH"},
+			{ID: 3, PrimaryAsset: "BTC", SecondaryAsset: "ETH"}},
+	}
+	it := (&Client{}).Order().Iterate(nil, OrderFilter{})
+	it.started = true
+	it.assets = []string{"AUD", "BTC", "ETH"}
+	it.page = func(asset string, limit, page int) ([]*Order, error) {
+		if page > 1 {
+			return nil, nil
+		}
+		return orders[asset], nil
+	}
+
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.Order().ID)
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Errorf("expected orders 1 to 3 once each, got %v", ids)
+	}
+}
+
+func TestOrderIteratorCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	it := (&Client{}).Order().Iterate(ctx, OrderFilter{Secondary: "BTC"})
+	if it.Next() || it.Err() != context.Canceled {
+		t.Errorf("expected the iterator to stop with the context's error, got %v", it.Err())
+	}
+}
+
+func TestCancelAllSearchesEveryAsset(t *testing.T) {
+	var requested, cancelled []string
+	c := &Client{ctx: context.Background(), httpConn: &http.Client{Transport: roundTripFunc(
+		func(req *http.Request) (*http.Response, error) {
+			body := "[]"
+			switch {
+			case req.Method == http.MethodDelete:
+				cancelled = append(cancelled, path.Base(req.URL.Path))
+				body = "{}"
+			case strings.HasSuffix(req.URL.Path, "/markets/assets/"):
+				body = `[{"id": 1, "code": "AUD"}, {"id": 3, "code": "BTC"},
+					{"id": 9, "code": "DOGE"}]`
+			case strings.HasSuffix(req.URL.Path, "/orders/DOGE"):
+				// an open order that is only listed under an asset with no balance
+				body = `[{"id": 5, "status": "1", "primary_asset": "AUD",
+					"secondary_asset": "DOGE"}]`
+			}
+			requested = append(requested, req.URL.Path)
+			return &http.Response{StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		})}}
+
+	ids, err := c.Order().CancelAll(context.Background(), OrderFilter{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 5 || len(cancelled) != 1 || cancelled[0] != "5" {
+		t.Errorf("expected order 5 to be cancelled, got %v", ids)
+	}
+	for _, p := range requested {
+		if strings.Contains(p, "balance") {
+			t.Errorf("expected balances not to limit the search, requested %s", p)
+		}
+	}
+}
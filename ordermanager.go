@@ -0,0 +1,588 @@
+package goswyftx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errManagedOrder = errors.New("managed order not found")
+
+// ManagedOrderKind is the kind of order a manager emulates
+type ManagedOrderKind string
+
+// Managed order kinds
+const (
+	// ManagedOCO has a resting limit sell at the take profit price and sells at market if
+	// the price falls to the stop price, whichever happens first cancels the other
+	ManagedOCO ManagedOrderKind = "oco"
+	// ManagedTrailingStop sells at market once the price falls a percentage below the highest
+	// price seen since it was added
+	ManagedTrailingStop ManagedOrderKind = "trailing_stop"
+	// ManagedTakeProfitLadder sells part of the quantity at market as the price reaches each
+	// level
+	ManagedTakeProfitLadder ManagedOrderKind = "take_profit_ladder"
+)
+
+// ManagedOrderState is the state of a managed order
+type ManagedOrderState string
+
+// Managed order states
+const (
+	ManagedActive    ManagedOrderState = "active"
+	ManagedDone      ManagedOrderState = "done"
+	ManagedCancelled ManagedOrderState = "cancelled"
+	// ManagedTriggering is saved before an exit order is placed. If a manager is restarted
+	// while an order is triggering it can't know whether the exit order was placed, so the
+	// order is moved to ManagedNeedsReview instead of risking selling twice
+	ManagedTriggering  ManagedOrderState = "triggering"
+	ManagedNeedsReview ManagedOrderState = "needs_review"
+)
+
+// LadderLevel is a single take profit level
+type LadderLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	OrderID  int     `json:"order_id,omitempty"`
+}
+
+// ManagedOrder is an order emulated by an OrderManager. Every managed order exits a position
+// by selling the secondary asset for the primary asset
+type ManagedOrder struct {
+	ID        string            `json:"id"`
+	Kind      ManagedOrderKind  `json:"kind"`
+	State     ManagedOrderState `json:"state"`
+	Primary   string            `json:"primary"`
+	Secondary string            `json:"secondary"`
+	Quantity  float64           `json:"quantity"`
+	Created   time.Time         `json:"created"`
+	Updated   time.Time         `json:"updated"`
+
+	StopPrice       float64 `json:"stop_price,omitempty"`
+	TakeProfitPrice float64 `json:"take_profit_price,omitempty"`
+	// LimitOrderID is the resting take profit order of an OCO. It is cleared once that order
+	// is cancelled or closes without filling, leaving only the stop price watched
+	LimitOrderID int `json:"limit_order_id,omitempty"`
+
+	TrailPercent float64 `json:"trail_percent,omitempty"`
+	HighWater    float64 `json:"high_water,omitempty"`
+
+	Levels []*LadderLevel `json:"levels,omitempty"`
+
+	// ExitOrderIDs are the market orders placed when the order triggered
+	ExitOrderIDs []int `json:"exit_order_ids,omitempty"`
+}
+
+// PriceFeed gives the latest price of a secondary asset in a primary asset
+type PriceFeed interface {
+	LatestPrice(primary, secondary string) (float64, error)
+}
+
+type liveRateFeed struct {
+	client   *Client
+	registry *AssetRegistry
+}
+
+// LatestPrice will return the live mid price of secondary in primary
+func (f *liveRateFeed) LatestPrice(primary, secondary string) (float64, error) {
+	if f.registry == nil {
+		registry, err := f.client.Market().Registry()
+		if err != nil {
+			return 0, fmt.Errorf("could not get assets: %s", err.Error())
+		}
+		f.registry = registry
+	}
+
+	base, ok := f.registry.ByCode(primary)
+	if !ok {
+		return 0, fmt.Errorf("unknown asset: %s", primary)
+	}
+	asset, ok := f.registry.ByCode(secondary)
+	if !ok {
+		return 0, fmt.Errorf("unknown asset: %s", secondary)
+	}
+
+	rates, err := f.client.Market().Rates(base.ID)
+	if err != nil {
+		return 0, err
+	}
+	rate, ok := rates[asset.ID]
+	if !ok {
+		return 0, fmt.Errorf("no live rate for %s", secondary)
+	}
+
+	return parseFloat(rate.MidPrice)
+}
+
+// OrderManager emulates OCO brackets, trailing stops and take profit ladders by watching live
+// rates and placing or cancelling real orders. Its state is saved to a file after every change
+// so a restarted manager carries on managing the same orders
+type OrderManager struct {
+	client  *Client
+	path    string
+	feed    PriceFeed
+	onError func(err error)
+
+	mu     sync.Mutex
+	orders map[string]*ManagedOrder
+	lastID int
+}
+
+// NewOrderManager will create an order manager that saves its state to statePath, loading any
+// orders already saved there
+func NewOrderManager(c *Client, statePath string) (*OrderManager, error) {
+	m := &OrderManager{
+		client: c,
+		path:   statePath,
+		feed:   &liveRateFeed{client: c},
+		orders: make(map[string]*ManagedOrder),
+	}
+
+	b, err := ioutil.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read order manager state: %s", err.Error())
+	}
+
+	var orders []*ManagedOrder
+	if err = json.Unmarshal(b, &orders); err != nil {
+		return nil, fmt.Errorf("could not decode order manager state: %s", err.Error())
+	}
+	for _, order := range orders {
+		if order.State == ManagedTriggering {
+			order.State = ManagedNeedsReview
+		}
+		m.orders[order.ID] = order
+		if id, err := strconv.Atoi(order.ID); err == nil && id > m.lastID {
+			m.lastID = id
+		}
+	}
+
+	return m, m.save()
+}
+
+// WithPriceFeed will replace the live rate price feed
+func (m *OrderManager) WithPriceFeed(feed PriceFeed) *OrderManager {
+	m.feed = feed
+	return m
+}
+
+// WithErrorHandler will set a function that is passed every failed poll while running
+func (m *OrderManager) WithErrorHandler(onError func(err error)) *OrderManager {
+	m.onError = onError
+	return m
+}
+
+// AddOCO will place a limit sell at takeProfit and sell at market if the price falls to stop.
+// The take profit price must be a whole number as that is all an order trigger holds, the
+// stop is watched by the manager so it can be any positive price
+func (m *OrderManager) AddOCO(primary, secondary string, quantity, stop,
+	takeProfit float64) (*ManagedOrder, error) {
+	if stop <= 0 || takeProfit <= stop {
+		return nil, errors.New("take profit must be above a positive stop price")
+	}
+	trigger, err := orderTrigger(takeProfit)
+	if err != nil {
+		return nil, fmt.Errorf("could not use take profit price: %s", err.Error())
+	}
+
+	limitID, err := m.client.Order().Place(&OrderPlace{
+		Primary:       primary,
+		Secondary:     secondary,
+		Quantity:      float32(quantity),
+		AssetQuantity: secondary,
+		OrderType:     OrderTypeLimitSell,
+		Trigger:       trigger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not place take profit order: %s", err.Error())
+	}
+
+	return m.add(&ManagedOrder{
+		Kind:            ManagedOCO,
+		Primary:         primary,
+		Secondary:       secondary,
+		Quantity:        quantity,
+		StopPrice:       stop,
+		TakeProfitPrice: takeProfit,
+		LimitOrderID:    limitID,
+	})
+}
+
+// AddTrailingStop will sell at market once the price falls trailPercent below the highest
+// price seen
+func (m *OrderManager) AddTrailingStop(primary, secondary string, quantity,
+	trailPercent float64) (*ManagedOrder, error) {
+	if trailPercent <= 0 || trailPercent >= 100 {
+		return nil, errors.New("trail percent must be between 0 and 100")
+	}
+
+	return m.add(&ManagedOrder{
+		Kind:         ManagedTrailingStop,
+		Primary:      primary,
+		Secondary:    secondary,
+		Quantity:     quantity,
+		TrailPercent: trailPercent,
+	})
+}
+
+// AddTakeProfitLadder will sell each level's quantity at market once the price reaches it
+func (m *OrderManager) AddTakeProfitLadder(primary, secondary string,
+	levels []LadderLevel) (*ManagedOrder, error) {
+	if len(levels) == 0 {
+		return nil, errors.New("a ladder needs at least one level")
+	}
+
+	order := &ManagedOrder{
+		Kind:      ManagedTakeProfitLadder,
+		Primary:   primary,
+		Secondary: secondary,
+	}
+	for _, level := range levels {
+		level := level
+		order.Quantity += level.Quantity
+		order.Levels = append(order.Levels, &level)
+	}
+	sort.Slice(order.Levels, func(i, j int) bool {
+		return order.Levels[i].Price < order.Levels[j].Price
+	})
+
+	return m.add(order)
+}
+
+func (m *OrderManager) add(order *ManagedOrder) (*ManagedOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	order.ID = strconv.Itoa(m.lastID)
+	order.State = ManagedActive
+	order.Created = time.Now()
+	order.Updated = order.Created
+	m.orders[order.ID] = order
+
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+
+	copied := *order
+	return &copied, nil
+}
+
+// Cancel will stop managing an order, cancelling its resting take profit order if it has one
+func (m *OrderManager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[id]
+	if !ok {
+		return errManagedOrder
+	}
+	if order.State != ManagedActive {
+		return fmt.Errorf("managed order %s is %s", id, order.State)
+	}
+
+	if order.LimitOrderID != 0 {
+		if err := m.client.Order().Cancel(order.LimitOrderID); err != nil {
+			return fmt.Errorf("could not cancel take profit order: %s", err.Error())
+		}
+	}
+
+	m.setState(order, ManagedCancelled)
+	return m.save()
+}
+
+// Orders are copies of every managed order ordered by ID
+func (m *OrderManager) Orders() []*ManagedOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	orders := make([]*ManagedOrder, 0, len(m.orders))
+	for _, order := range m.orders {
+		copied := *order
+		orders = append(orders, &copied)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		a, _ := strconv.Atoi(orders[i].ID)
+		b, _ := strconv.Atoi(orders[j].ID)
+		return a < b
+	})
+
+	return orders
+}
+
+// Run will poll prices every interval until ctx is done. A failed poll is passed to the error
+// handler and the orders are polled again on the next interval
+func (m *OrderManager) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Poll(); err != nil && m.onError != nil {
+			m.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll will check the price of every active order once and trigger any that have been hit.
+// An order that fails doesn't stop the rest from being checked, every failure is returned
+func (m *OrderManager) Poll() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var failed []string
+	prices := make(map[string]float64)
+	priceErrs := make(map[string]error)
+	statuses := make(map[string]map[int]string)
+	for _, order := range m.sortedActive() {
+		pair := buildString(strings.ToUpper(order.Primary), "/", strings.ToUpper(order.Secondary))
+		price, ok := prices[pair]
+		err := priceErrs[pair]
+		if !ok && err == nil {
+			if price, err = m.feed.LatestPrice(order.Primary, order.Secondary); err != nil {
+				err = fmt.Errorf("could not get price of %s: %s", pair, err.Error())
+				priceErrs[pair] = err
+			} else {
+				prices[pair] = price
+			}
+		}
+
+		if err == nil {
+			switch order.Kind {
+			case ManagedOCO:
+				err = m.pollOCO(order, price, statuses)
+			case ManagedTrailingStop:
+				err = m.pollTrailingStop(order, price)
+			case ManagedTakeProfitLadder:
+				err = m.pollLadder(order, price)
+			}
+		}
+		if err != nil {
+			failed = append(failed, buildString("managed order ", order.ID, ": ", err.Error()))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d managed orders failed: %s", len(failed), strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+func (m *OrderManager) sortedActive() []*ManagedOrder {
+	var active []*ManagedOrder
+	for _, order := range m.orders {
+		if order.State == ManagedActive {
+			active = append(active, order)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].Created.Before(active[j].Created)
+	})
+
+	return active
+}
+
+func (m *OrderManager) pollOCO(order *ManagedOrder, price float64,
+	statuses map[string]map[int]string) error {
+	if order.LimitOrderID != 0 {
+		// check whether the take profit order has closed, orders are only listed once per
+		// asset each poll
+		listed, ok := statuses[order.Secondary]
+		if !ok {
+			orders, err := m.client.Order().List(order.Secondary)
+			if err != nil {
+				return fmt.Errorf("could not list orders: %s", err.Error())
+			}
+			listed = make(map[int]string)
+			for _, o := range orders {
+				listed[o.ID] = o.Status
+			}
+			statuses[order.Secondary] = listed
+		}
+
+		switch listed[order.LimitOrderID] {
+		case OrderStatusCompleted:
+			m.setState(order, ManagedDone)
+			return m.save()
+		case OrderStatusCancelled, OrderStatusFailed, OrderStatusExpired,
+			OrderStatusSystemCancelled:
+			// closed outside the manager, only the stop is left to watch
+			order.LimitOrderID = 0
+			order.Updated = time.Now()
+			if err := m.save(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if price > order.StopPrice {
+		return nil
+	}
+
+	// the cancel is saved before selling so a failed exit is retried by the next poll rather
+	// than cancelling the same order again
+	if order.LimitOrderID != 0 {
+		if err := m.client.Order().Cancel(order.LimitOrderID); err != nil {
+			return fmt.Errorf("could not cancel take profit order: %s", err.Error())
+		}
+		order.LimitOrderID = 0
+		order.Updated = time.Now()
+		if err := m.save(); err != nil {
+			return err
+		}
+	}
+
+	return m.exit(order, order.Quantity)
+}
+
+func (m *OrderManager) pollTrailingStop(order *ManagedOrder, price float64) error {
+	if price > order.HighWater {
+		order.HighWater = price
+		order.Updated = time.Now()
+		return m.save()
+	}
+
+	if price > order.HighWater*(1-order.TrailPercent/100) {
+		return nil
+	}
+
+	return m.exit(order, order.Quantity)
+}
+
+func (m *OrderManager) pollLadder(order *ManagedOrder, price float64) error {
+	for _, level := range order.Levels {
+		if level.OrderID != 0 || price < level.Price {
+			continue
+		}
+
+		m.setState(order, ManagedTriggering)
+		if err := m.save(); err != nil {
+			return err
+		}
+
+		id, err := m.sell(order, level.Quantity)
+		if err != nil {
+			m.setState(order, ManagedActive)
+			if saveErr := m.save(); saveErr != nil {
+				return saveErr
+			}
+			return err
+		}
+		level.OrderID = id
+		order.ExitOrderIDs = append(order.ExitOrderIDs, id)
+		m.setState(order, ManagedActive)
+	}
+
+	done := true
+	for _, level := range order.Levels {
+		if level.OrderID == 0 {
+			done = false
+		}
+	}
+	if done {
+		m.setState(order, ManagedDone)
+	}
+
+	return m.save()
+}
+
+// exit will sell quantity at market and mark the order done, the triggering state is saved
+// first so a crash part way through can't lead to selling twice
+func (m *OrderManager) exit(order *ManagedOrder, quantity float64) error {
+	m.setState(order, ManagedTriggering)
+	if err := m.save(); err != nil {
+		return err
+	}
+
+	id, err := m.sell(order, quantity)
+	if err != nil {
+		m.setState(order, ManagedActive)
+		if saveErr := m.save(); saveErr != nil {
+			return saveErr
+		}
+		return err
+	}
+
+	order.ExitOrderIDs = append(order.ExitOrderIDs, id)
+	m.setState(order, ManagedDone)
+	return m.save()
+}
+
+func (m *OrderManager) sell(order *ManagedOrder, quantity float64) (int, error) {
+	id, err := m.client.Order().Place(&OrderPlace{
+		Primary:       order.Primary,
+		Secondary:     order.Secondary,
+		Quantity:      float32(quantity),
+		AssetQuantity: order.Secondary,
+		OrderType:     OrderTypeMarketSell,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not place exit order: %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (m *OrderManager) setState(order *ManagedOrder, state ManagedOrderState) {
+	order.State = state
+	order.Updated = time.Now()
+}
+
+// save will write every order to the state file, writing to a temporary file first so a crash
+// can't leave a half written state behind
+func (m *OrderManager) save() error {
+	orders := make([]*ManagedOrder, 0, len(m.orders))
+	for _, order := range m.orders {
+		orders = append(orders, order)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].Created.Before(orders[j].Created)
+	})
+
+	b, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode order manager state: %s", err.Error())
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(m.path), filepath.Base(m.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("could not save order manager state: %s", err.Error())
+	}
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), m.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("could not save order manager state: %s", err.Error())
+	}
+
+	return nil
+}
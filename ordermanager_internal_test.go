@@ -0,0 +1,102 @@
+package goswyftx
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixedPriceFeed gives every pair the same price
+type fixedPriceFeed float64
+
+func (f fixedPriceFeed) LatestPrice(primary, secondary string) (float64, error) {
+	return float64(f), nil
+}
+
+func TestOrderManagerOCORetriesExit(t *testing.T) {
+	tests := []struct {
+		name        string
+		limitStatus string
+		cancels     int
+	}{
+		{name: "open take profit", limitStatus: OrderStatusOpen, cancels: 1},
+		{name: "take profit cancelled outside the manager", limitStatus: OrderStatusCancelled},
+		{name: "take profit failed", limitStatus: OrderStatusFailed},
+	}
+
+	for _, test := range tests {
+		dir, err := ioutil.TempDir("", "ordermanager")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "orders.json")
+
+		state := `[{"id": "1", "kind": "oco", "state": "active", "primary": "AUD",
+			"secondary": "BTC", "quantity": 1, "stop_price": 100, "take_profit_price": 200,
+			"limit_order_id": 7}]`
+		if err = ioutil.WriteFile(path, []byte(state), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		var lists, cancels, sells int
+		c := &Client{ctx: context.Background(), httpConn: &http.Client{Transport: roundTripFunc(
+			func(req *http.Request) (*http.Response, error) {
+				status, body := http.StatusOK, "{}"
+				switch req.Method {
+				case http.MethodGet:
+					lists++
+					body = `[{"id": 7, "status": "` + test.limitStatus + `"}]`
+				case http.MethodDelete:
+					cancels++
+				case http.MethodPost:
+					// the first exit sell fails
+					if sells++; sells == 1 {
+						status = http.StatusInternalServerError
+						body = `{"error": {"error": "Server", "message": "try again"}}`
+					} else {
+						body = `{"orderId": 9}`
+					}
+				}
+				return &http.Response{StatusCode: status,
+					Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+			})}}
+
+		m, err := NewOrderManager(c, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.WithPriceFeed(fixedPriceFeed(90))
+
+		if err = m.Poll(); err == nil {
+			t.Errorf("%s: expected the first exit to fail", test.name)
+		}
+
+		// the closed take profit order is saved so a restart doesn't cancel it again
+		if m, err = NewOrderManager(c, path); err != nil {
+			t.Fatal(err)
+		}
+		m.WithPriceFeed(fixedPriceFeed(90))
+		if order := m.Orders()[0]; order.State != ManagedActive || order.LimitOrderID != 0 {
+			t.Errorf("%s: expected an active order without a take profit, got %+v", test.name,
+				order)
+		}
+
+		if err = m.Poll(); err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+		}
+		order := m.Orders()[0]
+		if order.State != ManagedDone || len(order.ExitOrderIDs) != 1 ||
+			order.ExitOrderIDs[0] != 9 {
+			t.Errorf("%s: expected the exit to be retried, got %+v", test.name, order)
+		}
+		if lists != 1 || cancels != test.cancels || sells != 2 {
+			t.Errorf("%s: expected 1 list, %d cancels and 2 sells, got %d, %d and %d",
+				test.name, test.cancels, lists, cancels, sells)
+		}
+	}
+}
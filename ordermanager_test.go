@@ -0,0 +1,140 @@
+package goswyftx_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joshturge/goswyftx"
+)
+
+type fakePriceFeed map[string]float64
+
+func (f fakePriceFeed) LatestPrice(primary, secondary string) (float64, error) {
+	price, ok := f[primary+"/"+secondary]
+	if !ok {
+		return 0, errors.New("feed is down")
+	}
+
+	return price, nil
+}
+
+func TestOrderManagerReloadTriggering(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ordermanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "orders.json")
+
+	state := `[
+		{"id": "1", "kind": "trailing_stop", "state": "triggering", "primary": "AUD",
+			"secondary": "BTC", "quantity": 1, "trail_percent": 5},
+		{"id": "2", "kind": "trailing_stop", "state": "active", "primary": "AUD",
+			"secondary": "BTC", "quantity": 1, "trail_percent": 5}
+	]`
+	if err = ioutil.WriteFile(path, []byte(state), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := goswyftx.NewOrderManager(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := m.Orders()
+	if len(orders) != 2 || orders[0].State != goswyftx.ManagedNeedsReview ||
+		orders[1].State != goswyftx.ManagedActive {
+		t.Fatalf("unexpected orders after reload: %+v %+v", orders[0], orders[1])
+	}
+
+	// the reviewed state is saved so it survives another restart
+	if m, err = goswyftx.NewOrderManager(nil, path); err != nil {
+		t.Fatal(err)
+	}
+	if order := m.Orders()[0]; order.State != goswyftx.ManagedNeedsReview {
+		t.Errorf("expected order 1 to still need review, got %s", order.State)
+	}
+
+	// new orders carry on from the highest loaded ID
+	order, err := m.AddTrailingStop("AUD", "ETH", 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != "3" {
+		t.Errorf("expected the new order to have ID 3, got %s", order.ID)
+	}
+}
+
+func TestOrderManagerPollContinuesPastFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ordermanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := goswyftx.NewOrderManager(nil, filepath.Join(dir, "orders.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.WithPriceFeed(fakePriceFeed{"AUD/ETH": 200})
+	if _, err = m.AddTrailingStop("AUD", "BTC", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.AddTrailingStop("AUD", "ETH", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Poll()
+	if err == nil || !strings.Contains(err.Error(), "AUD/BTC") {
+		t.Fatalf("expected the BTC price failure to be returned, got %v", err)
+	}
+	orders := m.Orders()
+	if orders[0].HighWater != 0 || orders[1].HighWater != 200 {
+		t.Errorf("expected only the ETH order to be polled, got high waters of %f and %f",
+			orders[0].HighWater, orders[1].HighWater)
+	}
+}
+
+func TestOrderManagerRejectsTakeProfit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ordermanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := goswyftx.NewOrderManager(nil, filepath.Join(dir, "orders.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the take profit would have to be moved to fit in an order trigger
+	for _, takeProfit := range []float64{0.6, 101.5} {
+		if _, err = m.AddOCO("AUD", "BTC", 1, 0.5, takeProfit); err == nil {
+			t.Errorf("%g: expected an error", takeProfit)
+		}
+	}
+	if orders := m.Orders(); len(orders) != 0 {
+		t.Errorf("expected no orders to be added, got %d", len(orders))
+	}
+}
+
+func TestOrderManagerRunRejectsInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ordermanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := goswyftx.NewOrderManager(nil, filepath.Join(dir, "orders.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.Run(context.Background(), 0); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
@@ -0,0 +1,435 @@
+package goswyftx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// parquetRowGroupSize is the number of rows buffered before a row group is written out
+const parquetRowGroupSize = 64 * 1024
+
+// decimal columns are written as DECIMAL(38, 18) in a 16 byte fixed length byte array, which
+// holds any quantity or price swyftx returns to 18 decimal places
+const (
+	parquetDecimalPrecision = 38
+	parquetDecimalScale     = 18
+	parquetDecimalSize      = 16
+)
+
+var (
+	parquetMagic = []byte("PAR1")
+	// parquetDecimalFactor scales a decimal to its unscaled integer
+	parquetDecimalFactor = new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10),
+		big.NewInt(parquetDecimalScale), nil))
+	// parquetDecimalMax is the smallest unscaled integer too large for the precision
+	parquetDecimalMax = new(big.Int).Exp(big.NewInt(10), big.NewInt(parquetDecimalPrecision), nil)
+	// parquetDecimalModulus is added to negative unscaled integers to get their two's
+	// complement
+	parquetDecimalModulus = new(big.Int).Lsh(big.NewInt(1), parquetDecimalSize*8)
+)
+
+// parquet type, encoding and repetition values from parquet.thrift
+const (
+	parquetInt64             = 2
+	parquetByteArray         = 6
+	parquetFixedLenByteArray = 7
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetConvertedUTF8    = 0
+	parquetConvertedDecimal = 5
+	parquetConvertedInt64   = 18
+
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetDataPage     = 0
+	parquetUncompressed = 0
+)
+
+type parquetColumnChunk struct {
+	offset     int64
+	size       int64
+	numValues  int64
+	columnName string
+	physical   int32
+}
+
+type parquetRowGroup struct {
+	columns []parquetColumnChunk
+	size    int64
+	rows    int64
+}
+
+// parquetColumn is a column being buffered for the current row group. Strings are written as
+// required UTF8 byte arrays, integers as optional INT64 and decimals as optional DECIMAL so an
+// empty value is written as a null
+type parquetColumn struct {
+	ExportColumn
+	data    bytes.Buffer
+	defined []bool
+}
+
+func (c *parquetColumn) physical() int32 {
+	switch c.Type {
+	case ExportInteger:
+		return parquetInt64
+	case ExportDecimal:
+		return parquetFixedLenByteArray
+	}
+
+	return parquetByteArray
+}
+
+func (c *parquetColumn) optional() bool {
+	return c.Type != ExportString
+}
+
+// encode will plain encode a value of the column, an empty value of an optional column is a
+// null and encodes to nothing
+func (c *parquetColumn) encode(value string) ([]byte, error) {
+	if c.optional() && value == "" {
+		return nil, nil
+	}
+
+	switch c.Type {
+	case ExportInteger:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("column %s is not an integer: %q", c.Name, value)
+		}
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, uint64(n))
+		return b, nil
+	case ExportDecimal:
+		b, err := parquetDecimal(value)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %s", c.Name, err.Error())
+		}
+		return b, nil
+	}
+
+	b := make([]byte, 4, 4+len(value))
+	binary.LittleEndian.PutUint32(b, uint32(len(value)))
+	return append(b, value...), nil
+}
+
+// parquetDecimal will convert a decimal string to its unscaled integer as a big endian two's
+// complement, digits past the scale are rounded half away from zero
+func parquetDecimal(value string) ([]byte, error) {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("not a decimal: %q", value)
+	}
+	r.Mul(r, parquetDecimalFactor)
+
+	unscaled, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Lsh(rem.Abs(rem), 1).Cmp(r.Denom()) >= 0 {
+		unscaled.Add(unscaled, big.NewInt(int64(r.Sign())))
+	}
+	if new(big.Int).Abs(unscaled).Cmp(parquetDecimalMax) >= 0 {
+		return nil, fmt.Errorf("%s has more than %d digits", value, parquetDecimalPrecision)
+	}
+
+	if unscaled.Sign() < 0 {
+		unscaled.Add(unscaled, parquetDecimalModulus)
+	}
+	b := make([]byte, parquetDecimalSize)
+	digits := unscaled.Bytes()
+	copy(b[len(b)-len(digits):], digits)
+
+	return b, nil
+}
+
+// parquetLevels will encode definition levels with a maximum level of 1 as runs of the RLE
+// hybrid encoding, prefixed with their length as version 1 data pages expect
+func parquetLevels(defined []bool) []byte {
+	var runs thriftWriter
+	for i := 0; i < len(defined); {
+		j := i
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		runs.varint(uint64(j-i) << 1)
+		if defined[i] {
+			runs.buf.WriteByte(1)
+		} else {
+			runs.buf.WriteByte(0)
+		}
+		i = j
+	}
+
+	b := make([]byte, 4, 4+runs.buf.Len())
+	binary.LittleEndian.PutUint32(b, uint32(runs.buf.Len()))
+	return append(b, runs.buf.Bytes()...)
+}
+
+// parquetWriter writes columns using plain encoding and no compression. Rows are buffered in
+// row groups so memory use stays bounded however many rows are written
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	columns   []*parquetColumn
+	rows      int
+	totalRows int64
+	groups    []parquetRowGroup
+}
+
+func newParquetWriter(w io.Writer, columns []ExportColumn) (*parquetWriter, error) {
+	pw := &parquetWriter{w: w}
+	for _, column := range columns {
+		pw.columns = append(pw.columns, &parquetColumn{ExportColumn: column})
+	}
+
+	if err := pw.write(parquetMagic); err != nil {
+		return nil, err
+	}
+
+	return pw, nil
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *parquetWriter) writeRow(values []string) error {
+	// every value is encoded before any are buffered so a bad row doesn't leave the columns
+	// with different lengths
+	encoded := make([][]byte, len(pw.columns))
+	for i, column := range pw.columns {
+		var err error
+		if encoded[i], err = column.encode(values[i]); err != nil {
+			return err
+		}
+	}
+	for i, column := range pw.columns {
+		column.defined = append(column.defined, encoded[i] != nil)
+		column.data.Write(encoded[i])
+	}
+	pw.rows++
+
+	if pw.rows >= parquetRowGroupSize {
+		return pw.flushRowGroup()
+	}
+
+	return nil
+}
+
+func (pw *parquetWriter) flushRowGroup() error {
+	if pw.rows == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{rows: int64(pw.rows)}
+	for _, column := range pw.columns {
+		var page []byte
+		if column.optional() {
+			page = parquetLevels(column.defined)
+		}
+		page = append(page, column.data.Bytes()...)
+
+		var header thriftWriter
+		header.i32Field(1, parquetDataPage)
+		header.i32Field(2, int32(len(page)))
+		header.i32Field(3, int32(len(page)))
+		header.structField(5, func() {
+			header.i32Field(1, int32(len(column.defined)))
+			header.i32Field(2, parquetPlain)
+			header.i32Field(3, parquetRLE)
+			header.i32Field(4, parquetRLE)
+		})
+		header.stop()
+
+		chunk := parquetColumnChunk{
+			offset:     pw.offset,
+			size:       int64(header.buf.Len() + len(page)),
+			numValues:  int64(len(column.defined)),
+			columnName: column.Name,
+			physical:   column.physical(),
+		}
+		if err := pw.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := pw.write(page); err != nil {
+			return err
+		}
+
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+		column.data.Reset()
+		column.defined = column.defined[:0]
+	}
+
+	pw.groups = append(pw.groups, group)
+	pw.totalRows += group.rows
+	pw.rows = 0
+
+	return nil
+}
+
+func (pw *parquetWriter) close() error {
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+
+	var meta thriftWriter
+	meta.i32Field(1, 1)
+	meta.listField(2, thriftStruct, len(pw.columns)+1)
+	meta.structElem(func() {
+		meta.binaryField(4, "schema")
+		meta.i32Field(5, int32(len(pw.columns)))
+	})
+	for _, column := range pw.columns {
+		column := column
+		meta.structElem(func() {
+			meta.i32Field(1, column.physical())
+			if column.Type == ExportDecimal {
+				meta.i32Field(2, parquetDecimalSize)
+			}
+			if column.optional() {
+				meta.i32Field(3, parquetOptional)
+			} else {
+				meta.i32Field(3, parquetRequired)
+			}
+			meta.binaryField(4, column.Name)
+			switch column.Type {
+			case ExportInteger:
+				meta.i32Field(6, parquetConvertedInt64)
+			case ExportDecimal:
+				meta.i32Field(6, parquetConvertedDecimal)
+				meta.i32Field(7, parquetDecimalScale)
+				meta.i32Field(8, parquetDecimalPrecision)
+			default:
+				meta.i32Field(6, parquetConvertedUTF8)
+			}
+		})
+	}
+	meta.i64Field(3, pw.totalRows)
+	meta.listField(4, thriftStruct, len(pw.groups))
+	for _, group := range pw.groups {
+		group := group
+		meta.structElem(func() {
+			meta.listField(1, thriftStruct, len(group.columns))
+			for _, chunk := range group.columns {
+				chunk := chunk
+				meta.structElem(func() {
+					meta.i64Field(2, chunk.offset)
+					meta.structField(3, func() {
+						meta.i32Field(1, chunk.physical)
+						meta.listField(2, thriftI32, 2)
+						meta.varint(zigzag(parquetPlain))
+						meta.varint(zigzag(parquetRLE))
+						meta.listField(3, thriftBinary, 1)
+						meta.binary(chunk.columnName)
+						meta.i32Field(4, parquetUncompressed)
+						meta.i64Field(5, chunk.numValues)
+						meta.i64Field(6, chunk.size)
+						meta.i64Field(7, chunk.size)
+						meta.i64Field(9, chunk.offset)
+					})
+				})
+			}
+			meta.i64Field(2, group.size)
+			meta.i64Field(3, group.rows)
+		})
+	}
+	meta.binaryField(6, "goswyftx")
+	meta.stop()
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(meta.buf.Len()))
+	for _, b := range [][]byte{meta.buf.Bytes(), length[:], parquetMagic} {
+		if err := pw.write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the subset of the thrift compact protocol needed for parquet metadata
+type thriftWriter struct {
+	buf    bytes.Buffer
+	lastID int16
+}
+
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+func (t *thriftWriter) varint(n uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], n)])
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) i32Field(id int16, n int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(n)))
+}
+
+func (t *thriftWriter) i64Field(id int16, n int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(n))
+}
+
+func (t *thriftWriter) binaryField(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binary(s)
+}
+
+func (t *thriftWriter) listField(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elemType)
+	t.varint(uint64(size))
+}
+
+func (t *thriftWriter) structField(id int16, fields func()) {
+	t.fieldHeader(id, thriftStruct)
+	t.structElem(fields)
+}
+
+func (t *thriftWriter) structElem(fields func()) {
+	last := t.lastID
+	t.lastID = 0
+	fields()
+	t.stop()
+	t.lastID = last
+}
+
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
@@ -0,0 +1,364 @@
+package goswyftx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInsufficientLots is returned when a disposal is larger than the lots held for an asset
+var ErrInsufficientLots = errors.New("disposal is larger than the quantity held")
+
+// LotMethod decides which lots a disposal is matched against
+type LotMethod int
+
+// Supported lot matching methods
+const (
+	// FIFO matches disposals against the oldest lots first
+	FIFO LotMethod = iota
+	// LIFO matches disposals against the newest lots first
+	LIFO
+	// AverageCost matches disposals against every lot in proportion so each unit has the same
+	// cost
+	AverageCost
+)
+
+// PriceSource gives the price of an asset in a quote currency at a point in time
+type PriceSource interface {
+	Price(asset string, at time.Time) (float64, error)
+}
+
+// Trade is a normalised acquisition or disposal of an asset
+type Trade struct {
+	Time  time.Time
+	Asset string
+	// Quantity of the asset, positive for an acquisition and negative for a disposal
+	Quantity float64
+	// Price of one unit of the asset in the quote currency
+	Price float64
+	// Fee paid in the quote currency
+	Fee   float64
+	Quote string
+	// Reference is the ID of the order or transaction the trade came from
+	Reference string
+}
+
+// Lot is a quantity of an asset acquired at the same time and cost
+type Lot struct {
+	Asset    string
+	Acquired time.Time
+	Quantity float64
+	// Cost of the whole lot including fees
+	Cost float64
+}
+
+// Disposal is a sale of an asset matched against the lots it came from
+type Disposal struct {
+	Asset    string
+	Time     time.Time
+	Quantity float64
+	// Proceeds of the sale less fees
+	Proceeds float64
+	Cost     float64
+	Gain     float64
+	// Lots are the portions of each lot that were disposed of
+	Lots      []*Lot
+	Reference string
+}
+
+// UnrealisedGain is the gain on an asset still held if it were sold at the current price
+type UnrealisedGain struct {
+	Asset    string
+	Quantity float64
+	Cost     float64
+	Value    float64
+	Gain     float64
+}
+
+// CostBasis tracks lots of each asset and matches disposals against them to work out
+// realised and unrealised profit and loss
+type CostBasis struct {
+	method    LotMethod
+	lots      map[string][]*Lot
+	disposals []*Disposal
+}
+
+// NewCostBasis will create a cost basis tracker that matches lots using method
+func NewCostBasis(method LotMethod) *CostBasis {
+	return &CostBasis{method: method, lots: make(map[string][]*Lot)}
+}
+
+// AddAll will sort trades by time and add each of them
+func (cb *CostBasis) AddAll(trades []*Trade) error {
+	sorted := make([]*Trade, len(trades))
+	copy(sorted, trades)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	for _, trade := range sorted {
+		if err := cb.Add(trade); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Add will record a trade, trades must be added in the order they happened
+func (cb *CostBasis) Add(trade *Trade) error {
+	asset := strings.ToUpper(trade.Asset)
+	if trade.Quantity > 0 {
+		cb.lots[asset] = append(cb.lots[asset], &Lot{
+			Asset:    asset,
+			Acquired: trade.Time,
+			Quantity: trade.Quantity,
+			Cost:     trade.Quantity*trade.Price + trade.Fee,
+		})
+		return nil
+	}
+	if trade.Quantity == 0 {
+		return nil
+	}
+
+	quantity := -trade.Quantity
+	matched, err := cb.match(asset, quantity)
+	if err != nil {
+		return fmt.Errorf("could not dispose of %g %s at %s: %s", quantity, asset,
+			trade.Time.Format(time.RFC3339), err.Error())
+	}
+
+	disposal := &Disposal{
+		Asset:     asset,
+		Time:      trade.Time,
+		Quantity:  quantity,
+		Proceeds:  quantity*trade.Price - trade.Fee,
+		Lots:      matched,
+		Reference: trade.Reference,
+	}
+	for _, lot := range matched {
+		disposal.Cost += lot.Cost
+	}
+	disposal.Gain = disposal.Proceeds - disposal.Cost
+	cb.disposals = append(cb.disposals, disposal)
+
+	return nil
+}
+
+// lotEpsilon absorbs floating point error so a lot that is fully disposed of is removed
+const lotEpsilon = 1e-12
+
+func (cb *CostBasis) match(asset string, quantity float64) ([]*Lot, error) {
+	lots := cb.lots[asset]
+
+	var held float64
+	for _, lot := range lots {
+		held += lot.Quantity
+	}
+	if quantity > held*(1+1e-9)+lotEpsilon {
+		return nil, ErrInsufficientLots
+	}
+
+	var matched []*Lot
+	take := func(lot *Lot, qty float64) {
+		if qty > lot.Quantity {
+			qty = lot.Quantity
+		}
+		cost := lot.Cost * qty / lot.Quantity
+		matched = append(matched, &Lot{asset, lot.Acquired, qty, cost})
+		lot.Quantity -= qty
+		lot.Cost -= cost
+	}
+
+	switch cb.method {
+	case AverageCost:
+		fraction := quantity / held
+		for _, lot := range lots {
+			take(lot, lot.Quantity*fraction)
+		}
+	case LIFO:
+		for i := len(lots) - 1; i >= 0 && quantity > lotEpsilon; i-- {
+			qty := lots[i].Quantity
+			take(lots[i], quantity)
+			quantity -= qty
+		}
+	default:
+		for i := 0; i < len(lots) && quantity > lotEpsilon; i++ {
+			qty := lots[i].Quantity
+			take(lots[i], quantity)
+			quantity -= qty
+		}
+	}
+
+	remaining := lots[:0]
+	for _, lot := range lots {
+		if lot.Quantity > lotEpsilon {
+			remaining = append(remaining, lot)
+		}
+	}
+	cb.lots[asset] = remaining
+
+	return matched, nil
+}
+
+// Disposals are every disposal recorded so far
+func (cb *CostBasis) Disposals() []*Disposal {
+	return cb.disposals
+}
+
+// Lots are the remaining lots held of an asset
+func (cb *CostBasis) Lots(asset string) []*Lot {
+	return cb.lots[strings.ToUpper(asset)]
+}
+
+// Realised will total the realised gain of each asset
+func (cb *CostBasis) Realised() map[string]float64 {
+	realised := make(map[string]float64)
+	for _, disposal := range cb.disposals {
+		realised[disposal.Asset] += disposal.Gain
+	}
+
+	return realised
+}
+
+// Unrealised will value the remaining lots of each asset at the given prices, keyed by asset
+// code. Assets without a price are skipped
+func (cb *CostBasis) Unrealised(prices map[string]float64) []*UnrealisedGain {
+	var gains []*UnrealisedGain
+	for asset, lots := range cb.lots {
+		price, ok := prices[asset]
+		if !ok || len(lots) == 0 {
+			continue
+		}
+
+		gain := &UnrealisedGain{Asset: asset}
+		for _, lot := range lots {
+			gain.Quantity += lot.Quantity
+			gain.Cost += lot.Cost
+		}
+		gain.Value = gain.Quantity * price
+		gain.Gain = gain.Value - gain.Cost
+		gains = append(gains, gain)
+	}
+	sort.Slice(gains, func(i, j int) bool {
+		return gains[i].Asset < gains[j].Asset
+	})
+
+	return gains
+}
+
+// LivePrices will get the current mid price of every asset in the registry against a base
+// asset, keyed by asset code, for use with CostBasis.Unrealised
+func (ms *MarketService) LivePrices(registry *AssetRegistry, baseAsset int) (map[string]float64,
+	error) {
+	rates, err := ms.Rates(baseAsset)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(rates))
+	for id, rate := range rates {
+		asset, ok := registry.ByID(id)
+		if !ok {
+			continue
+		}
+		price, err := parseFloat(rate.MidPrice)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse price of %s: %s", asset.Code, err.Error())
+		}
+		prices[strings.ToUpper(asset.Code)] = price
+	}
+
+	return prices, nil
+}
+
+// TradesFromOrders will convert completed orders into trades of the secondary asset priced in
+// the primary asset. Amount is taken as the quantity of the secondary asset filled and Price
+// as the price of one unit, Total is used to work out the price when Price isn't set. The fee
+// is left at zero for orders that don't report one
+func TradesFromOrders(orders []*Order) []*Trade {
+	var trades []*Trade
+	for _, order := range orders {
+		if order.Status != OrderStatusCompleted {
+			continue
+		}
+
+		quantity := float64(order.Amount)
+		if quantity == 0 && order.QuantityAsset == order.SecondaryAsset {
+			quantity = float64(order.Quantity)
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		price := float64(order.Price)
+		if price == 0 {
+			price = float64(order.Total) / quantity
+		}
+
+		fee, err := order.FeeAmount.Float64()
+		if err != nil {
+			fee = 0
+		}
+
+		if !IsBuyOrder(order.Type) {
+			quantity = -quantity
+		}
+		trades = append(trades, &Trade{
+			Time:      order.CreateTime.Time,
+			Asset:     order.SecondaryAsset,
+			Quantity:  quantity,
+			Price:     price,
+			Fee:       fee,
+			Quote:     order.PrimaryAsset,
+			Reference: strconv.Itoa(order.ID),
+		})
+	}
+
+	return trades
+}
+
+// TradesFromHistory will convert completed buy and sell transactions into trades. History
+// doesn't include the price of a transaction so it is looked up from prices in the quote
+// currency
+func TradesFromHistory(history []*TransactionHistory, registry *AssetRegistry, quote string,
+	prices PriceSource) ([]*Trade, error) {
+	var trades []*Trade
+	for _, transaction := range history {
+		if ClassifyHistoryStatus(transaction.Status) != HistoryStatusCompleted {
+			continue
+		}
+
+		quantity := float64(transaction.Amount)
+		switch strings.ToLower(transaction.ActionType) {
+		case "buy":
+		case "sell":
+			quantity = -quantity
+		default:
+			continue
+		}
+
+		asset, ok := registry.ByID(transaction.Asset)
+		if !ok {
+			return nil, fmt.Errorf("unknown asset: %d", transaction.Asset)
+		}
+
+		price, err := prices.Price(asset.Code, transaction.Updated.Time)
+		if err != nil {
+			return nil, fmt.Errorf("could not get price of %s: %s", asset.Code, err.Error())
+		}
+
+		trades = append(trades, &Trade{
+			Time:     transaction.Updated.Time,
+			Asset:    asset.Code,
+			Quantity: quantity,
+			Price:    price,
+			Quote:    quote,
+		})
+	}
+
+	return trades, nil
+}
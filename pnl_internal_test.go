@@ -0,0 +1,70 @@
+package goswyftx
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLivePrices(t *testing.T) {
+	registry := NewAssetRegistry([]*MarketAsset{{ID: 1, Code: "AUD"}, {ID: 3, Code: "btc"},
+		{ID: 5, Code: "ETH"}})
+
+	tests := []struct {
+		name   string
+		rates  string
+		prices map[string]float64
+		err    bool
+	}{
+		{
+			name:   "prices keyed by upper case code",
+			rates:  `{"3": {"midPrice": "50000"}, "5": {"midPrice": "2000.5"}}`,
+			prices: map[string]float64{"BTC": 50000, "ETH": 2000.5},
+		},
+		{
+			name:   "unknown assets are skipped",
+			rates:  `{"3": {"midPrice": "50000"}, "99": {"midPrice": "1"}}`,
+			prices: map[string]float64{"BTC": 50000},
+		},
+		{
+			name:   "missing price",
+			rates:  `{"3": {}}`,
+			prices: map[string]float64{"BTC": 0},
+		},
+		{name: "unparsable price", rates: `{"3": {"midPrice": "lots"}}`, err: true},
+	}
+
+	for _, test := range tests {
+		c := &Client{ctx: context.Background(), httpConn: &http.Client{Transport: roundTripFunc(
+			func(req *http.Request) (*http.Response, error) {
+				if !strings.HasSuffix(req.URL.Path, "/live-rates/1") {
+					t.Errorf("%s: unexpected request %s", test.name, req.URL)
+				}
+				return &http.Response{StatusCode: http.StatusOK,
+					Body: ioutil.NopCloser(strings.NewReader(test.rates))}, nil
+			})}}
+
+		prices, err := c.Market().LivePrices(registry, 1)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+		if len(prices) != len(test.prices) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.prices, prices)
+			continue
+		}
+		for code, price := range test.prices {
+			if got, ok := prices[code]; !ok || got != price {
+				t.Errorf("%s: expected %s at %g, got %v", test.name, code, price, prices)
+			}
+		}
+	}
+}
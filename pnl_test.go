@@ -0,0 +1,209 @@
+package goswyftx_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func TestCostBasis(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	trades := []*goswyftx.Trade{
+		{Time: start, Asset: "BTC", Quantity: 1, Price: 100},
+		{Time: start.Add(time.Hour), Asset: "BTC", Quantity: 1, Price: 200},
+		{Time: start.Add(2 * time.Hour), Asset: "BTC", Quantity: -1, Price: 300},
+	}
+
+	tests := []struct {
+		method     goswyftx.LotMethod
+		realised   float64
+		unrealised float64
+	}{
+		{goswyftx.FIFO, 200, 300},
+		{goswyftx.LIFO, 100, 400},
+		{goswyftx.AverageCost, 150, 350},
+	}
+
+	for _, test := range tests {
+		cb := goswyftx.NewCostBasis(test.method)
+		if err := cb.AddAll(trades); err != nil {
+			t.Fatal(err)
+		}
+
+		if realised := cb.Realised()["BTC"]; math.Abs(realised-test.realised) > 1e-9 {
+			t.Errorf("method %d: expected realised gain of %f, got %f", test.method,
+				test.realised, realised)
+		}
+
+		gains := cb.Unrealised(map[string]float64{"BTC": 500})
+		if len(gains) != 1 || math.Abs(gains[0].Gain-test.unrealised) > 1e-9 {
+			t.Errorf("method %d: unexpected unrealised gains: %+v", test.method, gains)
+		}
+	}
+}
+
+func TestCostBasisInsufficientLots(t *testing.T) {
+	cb := goswyftx.NewCostBasis(goswyftx.FIFO)
+	err := cb.Add(&goswyftx.Trade{Asset: "ETH", Quantity: -1, Price: 10})
+	if err == nil {
+		t.Error("expected an error disposing of an asset that isn't held")
+	}
+}
+
+func TestTradesFromOrders(t *testing.T) {
+	created := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		order  *goswyftx.Order
+		trades []goswyftx.Trade
+	}{
+		{
+			name: "buy",
+			order: &goswyftx.Order{ID: 1, Type: goswyftx.OrderTypeMarketBuy,
+				PrimaryAsset: "AUD", SecondaryAsset: "BTC", Amount: 2, Price: 100,
+				Status: goswyftx.OrderStatusCompleted},
+			trades: []goswyftx.Trade{{Asset: "BTC", Quantity: 2, Price: 100, Quote: "AUD",
+				Reference: "1"}},
+		},
+		{
+			name: "sell with a fee",
+			order: &goswyftx.Order{ID: 2, Type: goswyftx.OrderTypeLimitSell,
+				PrimaryAsset: "AUD", SecondaryAsset: "BTC", Amount: 3, Price: 200,
+				FeeAmount: "3.6", Status: goswyftx.OrderStatusCompleted},
+			trades: []goswyftx.Trade{{Asset: "BTC", Quantity: -3, Price: 200, Fee: 3.6,
+				Quote: "AUD", Reference: "2"}},
+		},
+		{
+			name: "price from the total",
+			order: &goswyftx.Order{ID: 3, Type: goswyftx.OrderTypeMarketBuy,
+				PrimaryAsset: "AUD", SecondaryAsset: "ETH", Amount: 4, Total: 10,
+				Status: goswyftx.OrderStatusCompleted},
+			trades: []goswyftx.Trade{{Asset: "ETH", Quantity: 4, Price: 2.5, Quote: "AUD",
+				Reference: "3"}},
+		},
+		{
+			name: "quantity in the secondary asset",
+			order: &goswyftx.Order{ID: 4, Type: goswyftx.OrderTypeMarketBuy,
+				PrimaryAsset: "AUD", SecondaryAsset: "ETH", QuantityAsset: "ETH", Quantity: 5,
+				Price: 2, Status: goswyftx.OrderStatusCompleted},
+			trades: []goswyftx.Trade{{Asset: "ETH", Quantity: 5, Price: 2, Quote: "AUD",
+				Reference: "4"}},
+		},
+		{
+			name: "open",
+			order: &goswyftx.Order{ID: 5, Type: goswyftx.OrderTypeMarketBuy, Amount: 1,
+				Price: 1, Status: goswyftx.OrderStatusOpen},
+		},
+		{
+			name: "nothing filled",
+			order: &goswyftx.Order{ID: 6, Type: goswyftx.OrderTypeMarketBuy,
+				PrimaryAsset: "AUD", SecondaryAsset: "ETH", QuantityAsset: "AUD", Quantity: 5,
+				Status: goswyftx.OrderStatusCompleted},
+		},
+	}
+
+	for _, test := range tests {
+		test.order.CreateTime = goswyftx.SwyftxTime{Time: created}
+		trades := goswyftx.TradesFromOrders([]*goswyftx.Order{test.order})
+		if len(trades) != len(test.trades) {
+			t.Errorf("%s: expected %d trades, got %d", test.name, len(test.trades), len(trades))
+			continue
+		}
+		for i, expected := range test.trades {
+			expected.Time = created
+			if got := *trades[i]; got != expected {
+				t.Errorf("%s: expected %+v, got %+v", test.name, expected, got)
+			}
+		}
+	}
+}
+
+type historyPrices map[string]float64
+
+func (p historyPrices) Price(asset string, at time.Time) (float64, error) {
+	price, ok := p[asset]
+	if !ok {
+		return 0, errors.New("no price")
+	}
+
+	return price, nil
+}
+
+func TestTradesFromHistory(t *testing.T) {
+	updated := goswyftx.SwyftxTime{Time: time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)}
+	registry := goswyftx.NewAssetRegistry([]*goswyftx.MarketAsset{{ID: 3, Code: "BTC"},
+		{ID: 5, Code: "ETH"}})
+	prices := historyPrices{"BTC": 50000}
+
+	tests := []struct {
+		name        string
+		transaction *goswyftx.TransactionHistory
+		trades      []goswyftx.Trade
+		err         bool
+	}{
+		{
+			name: "buy",
+			transaction: &goswyftx.TransactionHistory{Asset: 3, Amount: 0.5, ActionType: "Buy",
+				Status: "Completed"},
+			trades: []goswyftx.Trade{{Asset: "BTC", Quantity: 0.5, Price: 50000, Quote: "AUD"}},
+		},
+		{
+			name: "sell",
+			transaction: &goswyftx.TransactionHistory{Asset: 3, Amount: 0.25,
+				ActionType: "sell", Status: "completed"},
+			trades: []goswyftx.Trade{{Asset: "BTC", Quantity: -0.25, Price: 50000,
+				Quote: "AUD"}},
+		},
+		{
+			name: "pending",
+			transaction: &goswyftx.TransactionHistory{Asset: 3, Amount: 1, ActionType: "Buy",
+				Status: "Pending"},
+		},
+		{
+			name: "deposit",
+			transaction: &goswyftx.TransactionHistory{Asset: 3, Amount: 1,
+				ActionType: "Deposit", Status: "Completed"},
+		},
+		{
+			name: "missing price",
+			transaction: &goswyftx.TransactionHistory{Asset: 5, Amount: 1, ActionType: "Buy",
+				Status: "Completed"},
+			err: true,
+		},
+		{
+			name: "unknown asset",
+			transaction: &goswyftx.TransactionHistory{Asset: 99, Amount: 1, ActionType: "Buy",
+				Status: "Completed"},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		test.transaction.Updated = updated
+		trades, err := goswyftx.TradesFromHistory([]*goswyftx.TransactionHistory{
+			test.transaction}, registry, "AUD", prices)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+		if len(trades) != len(test.trades) {
+			t.Errorf("%s: expected %d trades, got %d", test.name, len(test.trades), len(trades))
+			continue
+		}
+		for i, expected := range test.trades {
+			expected.Time = updated.Time
+			if got := *trades[i]; got != expected {
+				t.Errorf("%s: expected %+v, got %+v", test.name, expected, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package goswyftx
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PortfolioService values an account's holdings in its default currency
+type PortfolioService service
+
+// PortfolioHolding is the value of a single asset in a portfolio
+type PortfolioHolding struct {
+	AssetID  int
+	Code     string
+	Quantity float64
+	// Price of one unit of the asset in the portfolio currency
+	Price float64
+	Value float64
+	// Allocation is the percentage of the portfolio total this holding makes up
+	Allocation float64
+	// DailyChange is the percentage the price has changed over the last 24 hours
+	DailyChange float64
+}
+
+// PortfolioSnapshot is the value of an account at a point in time
+type PortfolioSnapshot struct {
+	Time       time.Time
+	CurrencyID int
+	Currency   string
+	// Holdings ordered by value, largest first
+	Holdings []*PortfolioHolding
+	Total    float64
+}
+
+// PortfolioChange is how a single holding changed between two snapshots
+type PortfolioChange struct {
+	AssetID          int
+	Code             string
+	QuantityChange   float64
+	PriceChange      float64
+	ValueChange      float64
+	AllocationChange float64
+}
+
+// PortfolioDiff is what changed between two snapshots
+type PortfolioDiff struct {
+	From        time.Time
+	To          time.Time
+	TotalChange float64
+	// Changes for every asset held in either snapshot that changed
+	Changes []*PortfolioChange
+}
+
+// Portfolio will return a portfolio service that can value the account's holdings
+func (c *Client) Portfolio() *PortfolioService {
+	return (*PortfolioService)(&service{c})
+}
+
+// Snapshot will value every non zero balance in the account's default currency
+func (ps *PortfolioService) Snapshot() (*PortfolioSnapshot, error) {
+	profile, err := ps.client.Account().Profile()
+	if err != nil {
+		return nil, fmt.Errorf("could not get profile: %s", err.Error())
+	}
+
+	balances, err := ps.client.Account().Balance()
+	if err != nil {
+		return nil, fmt.Errorf("could not get balances: %s", err.Error())
+	}
+
+	registry, err := ps.client.Market().Registry()
+	if err != nil {
+		return nil, fmt.Errorf("could not get assets: %s", err.Error())
+	}
+
+	rates, err := ps.client.Market().Rates(profile.Currency.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get live rates: %s", err.Error())
+	}
+
+	snapshot := &PortfolioSnapshot{
+		Time:       time.Now(),
+		CurrencyID: profile.Currency.ID,
+		Currency:   profile.Currency.Code,
+	}
+	for _, balance := range balances {
+		quantity, err := parseFloat(balance.AvailableBalance)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse balance of asset %d: %s", balance.AssetID,
+				err.Error())
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		holding := &PortfolioHolding{AssetID: balance.AssetID, Quantity: quantity}
+		if asset, ok := registry.ByID(balance.AssetID); ok {
+			holding.Code = asset.Code
+		}
+
+		if err = ps.price(holding, rates, profile.Currency.ID); err != nil {
+			return nil, err
+		}
+		holding.Value = holding.Quantity * holding.Price
+		snapshot.Total += holding.Value
+		snapshot.Holdings = append(snapshot.Holdings, holding)
+	}
+
+	for _, holding := range snapshot.Holdings {
+		if snapshot.Total != 0 {
+			holding.Allocation = holding.Value / snapshot.Total * 100
+		}
+	}
+	sort.SliceStable(snapshot.Holdings, func(i, j int) bool {
+		return snapshot.Holdings[i].Value > snapshot.Holdings[j].Value
+	})
+
+	return snapshot, nil
+}
+
+func (ps *PortfolioService) price(holding *PortfolioHolding, rates map[int]*MarketRate,
+	currencyID int) (err error) {
+	if holding.AssetID == currencyID {
+		holding.Price = 1
+		return nil
+	}
+
+	if rate, ok := rates[holding.AssetID]; ok {
+		if holding.Price, err = parseFloat(rate.MidPrice); err != nil {
+			return fmt.Errorf("could not parse price of asset %d: %s", holding.AssetID, err.Error())
+		}
+		if holding.DailyChange, err = parseFloat(rate.DailyPriceChange); err != nil {
+			return fmt.Errorf("could not parse daily change of asset %d: %s", holding.AssetID,
+				err.Error())
+		}
+		return nil
+	}
+
+	// fall back to the mid of the buy and sell price when there is no live rate
+	if isEmptyStr(holding.Code) {
+		return fmt.Errorf("no price for asset %d", holding.AssetID)
+	}
+	info, err := ps.client.Market().BasicInfo(holding.Code)
+	if err != nil {
+		return fmt.Errorf("could not get basic info for %s: %s", holding.Code, err.Error())
+	}
+	buy, err := parseFloat(info.Buy)
+	if err != nil {
+		return fmt.Errorf("could not parse buy price of %s: %s", holding.Code, err.Error())
+	}
+	sell, err := parseFloat(info.Sell)
+	if err != nil {
+		return fmt.Errorf("could not parse sell price of %s: %s", holding.Code, err.Error())
+	}
+	holding.Price = (buy + sell) / 2
+
+	return nil
+}
+
+// Holding will return the holding of an asset in the snapshot or nil if it isn't held
+func (s *PortfolioSnapshot) Holding(assetID int) *PortfolioHolding {
+	for _, holding := range s.Holdings {
+		if holding.AssetID == assetID {
+			return holding
+		}
+	}
+
+	return nil
+}
+
+// Diff will show what changed between a previous snapshot and this one
+func (s *PortfolioSnapshot) Diff(prev *PortfolioSnapshot) *PortfolioDiff {
+	diff := &PortfolioDiff{
+		From:        prev.Time,
+		To:          s.Time,
+		TotalChange: s.Total - prev.Total,
+	}
+
+	seen := make(map[int]bool)
+	add := func(before, after *PortfolioHolding) {
+		var empty PortfolioHolding
+		if before == nil {
+			before = &empty
+		}
+		if after == nil {
+			after = &empty
+		}
+
+		change := &PortfolioChange{
+			AssetID:          after.AssetID,
+			Code:             after.Code,
+			QuantityChange:   after.Quantity - before.Quantity,
+			PriceChange:      after.Price - before.Price,
+			ValueChange:      after.Value - before.Value,
+			AllocationChange: after.Allocation - before.Allocation,
+		}
+		if after == &empty {
+			change.AssetID, change.Code = before.AssetID, before.Code
+		}
+		if change.QuantityChange != 0 || change.PriceChange != 0 || change.ValueChange != 0 {
+			diff.Changes = append(diff.Changes, change)
+		}
+	}
+
+	for _, holding := range s.Holdings {
+		seen[holding.AssetID] = true
+		add(prev.Holding(holding.AssetID), holding)
+	}
+	for _, holding := range prev.Holdings {
+		if !seen[holding.AssetID] {
+			add(holding, nil)
+		}
+	}
+
+	return diff
+}
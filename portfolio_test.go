@@ -0,0 +1,130 @@
+package goswyftx
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc lets a function stand in for the swyftx api
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestPortfolioDiff(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	prev := &PortfolioSnapshot{
+		Time: start,
+		Holdings: []*PortfolioHolding{
+			{AssetID: 1, Code: "AUD", Quantity: 500, Price: 1, Value: 500, Allocation: 50},
+			{AssetID: 3, Code: "BTC", Quantity: 0.01, Price: 40000, Value: 400, Allocation: 40},
+			{AssetID: 5, Code: "ETH", Quantity: 0.05, Price: 2000, Value: 100, Allocation: 10},
+		},
+		Total: 1000,
+	}
+	current := &PortfolioSnapshot{
+		Time: start.Add(time.Hour),
+		Holdings: []*PortfolioHolding{
+			// unchanged so it isn't in the diff
+			{AssetID: 1, Code: "AUD", Quantity: 500, Price: 1, Value: 500, Allocation: 40},
+			{AssetID: 3, Code: "BTC", Quantity: 0.01, Price: 50000, Value: 500, Allocation: 40},
+			{AssetID: 7, Code: "XRP", Quantity: 250, Price: 1, Value: 250, Allocation: 20},
+		},
+		Total: 1250,
+	}
+
+	diff := current.Diff(prev)
+	if !diff.From.Equal(prev.Time) || !diff.To.Equal(current.Time) || diff.TotalChange != 250 {
+		t.Errorf("unexpected diff of %g from %s to %s", diff.TotalChange, diff.From, diff.To)
+	}
+
+	expected := []PortfolioChange{
+		// changed, then added and finally removed holdings
+		{AssetID: 3, Code: "BTC", PriceChange: 10000, ValueChange: 100},
+		{AssetID: 7, Code: "XRP", QuantityChange: 250, PriceChange: 1, ValueChange: 250,
+			AllocationChange: 20},
+		{AssetID: 5, Code: "ETH", QuantityChange: -0.05, PriceChange: -2000, ValueChange: -100,
+			AllocationChange: -10},
+	}
+	if len(diff.Changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d", len(expected), len(diff.Changes))
+	}
+	for i, e := range expected {
+		got := diff.Changes[i]
+		if got.AssetID != e.AssetID || got.Code != e.Code ||
+			math.Abs(got.QuantityChange-e.QuantityChange) > 1e-9 ||
+			math.Abs(got.PriceChange-e.PriceChange) > 1e-9 ||
+			math.Abs(got.ValueChange-e.ValueChange) > 1e-9 ||
+			math.Abs(got.AllocationChange-e.AllocationChange) > 1e-9 {
+			t.Errorf("%d: expected %+v, got %+v", i, e, *got)
+		}
+	}
+
+	if diff = current.Diff(current); len(diff.Changes) != 0 || diff.TotalChange != 0 {
+		t.Errorf("expected no changes against itself, got %+v", diff)
+	}
+}
+
+func TestPortfolioPrice(t *testing.T) {
+	var requested []string
+	c := &Client{ctx: context.Background(), httpConn: &http.Client{Transport: roundTripFunc(
+		func(req *http.Request) (*http.Response, error) {
+			requested = append(requested, req.URL.Path)
+			body := `{"code": "DOGE", "buy": "0.12", "sell": "0.10"}`
+			if strings.HasSuffix(req.URL.Path, "/BAD") {
+				body = `{"code": "BAD", "buy": "lots", "sell": "0.10"}`
+			}
+			return &http.Response{StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		})}}
+	ps := c.Portfolio()
+	rates := map[int]*MarketRate{3: {MidPrice: "50000", DailyPriceChange: "-2.5"}}
+
+	tests := []struct {
+		name    string
+		holding *PortfolioHolding
+		price   float64
+		change  float64
+		err     bool
+	}{
+		{name: "portfolio currency", holding: &PortfolioHolding{AssetID: 1, Code: "AUD"},
+			price: 1},
+		{name: "live rate", holding: &PortfolioHolding{AssetID: 3, Code: "BTC"}, price: 50000,
+			change: -2.5},
+		{name: "basic info fallback", holding: &PortfolioHolding{AssetID: 9, Code: "DOGE"},
+			price: 0.11},
+		{name: "unparsable basic info", holding: &PortfolioHolding{AssetID: 10, Code: "BAD"},
+			err: true},
+		{name: "unknown asset", holding: &PortfolioHolding{AssetID: 11}, err: true},
+	}
+
+	for _, test := range tests {
+		err := ps.price(test.holding, rates, 1)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+		if math.Abs(test.holding.Price-test.price) > 1e-9 ||
+			test.holding.DailyChange != test.change {
+			t.Errorf("%s: expected a price of %g and change of %g, got %g and %g", test.name,
+				test.price, test.change, test.holding.Price, test.holding.DailyChange)
+		}
+	}
+
+	// only the assets without a live rate are looked up
+	if len(requested) != 2 || requested[0] != "/markets/info/basic/DOGE" {
+		t.Errorf("unexpected requests %v", requested)
+	}
+}
@@ -0,0 +1,157 @@
+package goswyftx
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultFeeRate is the fraction of an order's value swyftx charges as a fee
+const DefaultFeeRate = 0.006
+
+// Quote is the expected result of placing an order
+type Quote struct {
+	// Price is the expected fill price of one unit of the secondary asset in the primary asset
+	Price float64
+	// Mid is the live mid price of the secondary asset in the primary asset
+	Mid float64
+	// Spread is the fraction the fill price deviates from the mid price
+	Spread float64
+	// Quantity of the secondary asset the order is expected to fill
+	Quantity float64
+	// Value of the order in the primary asset before fees
+	Value float64
+	// Fee is the expected fee in the primary asset
+	Fee float64
+}
+
+// PlaceOption changes how an order is placed
+type PlaceOption func(*placeOptions)
+
+type placeOptions struct {
+	maxSlippage float64
+	maxCost     float64
+}
+
+// WithSlippageGuard will quote a market order before placing it and refuse to place it with
+// ErrSlippageExceeded if the quoted price deviates from the live mid price by more than
+// tolerance, a fraction such as 0.01 for 1%. The quote has the same limitation as Quote, an
+// order for less than one unit is checked against the spread of a whole unit
+func WithSlippageGuard(tolerance float64) PlaceOption {
+	return func(opts *placeOptions) {
+		opts.maxSlippage = tolerance
+	}
+}
+
+func (os *OrderService) guardSlippage(order *OrderPlace, opts *placeOptions) error {
+	if opts.maxSlippage <= 0 ||
+		order.OrderType != OrderTypeMarketBuy && order.OrderType != OrderTypeMarketSell {
+		return nil
+	}
+
+	quote, err := os.Quote(order)
+	if err != nil {
+		return fmt.Errorf("could not quote order: %s", err.Error())
+	}
+	if quote.Spread > opts.maxSlippage {
+		return fmt.Errorf("%w: price %g is %.2f%% from the mid price %g", ErrSlippageExceeded,
+			quote.Price, quote.Spread*100, quote.Mid)
+	}
+
+	return nil
+}
+
+// Quote will get the expected fill price of an order from swyftx and compare it with the live
+// mid price. The fee is estimated at DefaultFeeRate.
+//
+// PairExchangeRate only takes a whole amount so the price is quoted for the quantity rounded
+// up to a whole unit, an order for 0.01 BTC is priced as if it were for 1 BTC. The quote's
+// Quantity, Value and Fee are still for the order's real quantity, but the Price and Spread
+// of a small order can be worse than it would fill at
+func (os *OrderService) Quote(order *OrderPlace) (*Quote, error) {
+	buy, sell := order.Secondary, order.Primary
+	if !IsBuyOrder(order.OrderType) {
+		buy, sell = sell, buy
+	}
+	limit := order.AssetQuantity
+	if isEmptyStr(limit) {
+		limit = order.Secondary
+	}
+
+	rate, err := os.PairExchangeRate(buy, sell, quoteAmount(float64(order.Quantity)), limit)
+	if err != nil {
+		return nil, err
+	}
+	price, err := quotePrice(rate, IsBuyOrder(order.OrderType))
+	if err != nil {
+		return nil, err
+	}
+
+	mid, err := os.midPrice(order.Primary, order.Secondary)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &Quote{Price: price, Mid: mid, Quantity: float64(order.Quantity)}
+	if strings.EqualFold(order.AssetQuantity, order.Primary) {
+		quote.Quantity /= price
+	}
+	quote.Value = quote.Quantity * price
+	quote.Fee = quote.Value * DefaultFeeRate
+	if mid > 0 {
+		quote.Spread = math.Abs(price-mid) / mid
+	}
+
+	return quote, nil
+}
+
+// quotePrice is the price of a pair exchange rate in the primary asset. The rate is priced in
+// the asset being sold so a sell's price is inverted
+func quotePrice(rate *OrderExchangeRate, buy bool) (float64, error) {
+	price, err := parseFloat(rate.Price)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("invalid quote price: %q", rate.Price)
+	}
+	if !buy {
+		price = 1 / price
+	}
+
+	return price, nil
+}
+
+// quoteAmount is the whole amount swyftx quotes for a quantity, it is rounded up so a
+// fractional quantity is never quoted for less than it is
+func quoteAmount(quantity float64) int {
+	return int(math.Max(1, math.Ceil(quantity)))
+}
+
+func (os *OrderService) midPrice(primary, secondary string) (float64, error) {
+	registry, err := os.client.registry()
+	if err != nil {
+		return 0, fmt.Errorf("could not get assets: %s", err.Error())
+	}
+	base, ok := registry.ByCode(primary)
+	if !ok {
+		return 0, fmt.Errorf("unknown asset: %s", primary)
+	}
+	asset, ok := registry.ByCode(secondary)
+	if !ok {
+		return 0, fmt.Errorf("unknown asset: %s", secondary)
+	}
+
+	rates, err := os.client.Market().Rates(base.ID)
+	if err != nil {
+		return 0, fmt.Errorf("could not get live rates: %s", err.Error())
+	}
+	rate, ok := rates[asset.ID]
+	if !ok {
+		return 0, fmt.Errorf("no live rate for %s", secondary)
+	}
+
+	mid, err := parseFloat(rate.MidPrice)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse mid price of %s: %s", secondary, err.Error())
+	}
+
+	return mid, nil
+}
@@ -0,0 +1,37 @@
+package goswyftx
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuotePrice(t *testing.T) {
+	tests := []struct {
+		rate     string
+		buy      bool
+		expected float64
+		err      bool
+	}{
+		// buying BTC with AUD is priced in AUD
+		{rate: "50000", buy: true, expected: 50000},
+		// selling BTC for AUD is priced in BTC
+		{rate: "0.00002", buy: false, expected: 50000},
+		{rate: "0", buy: true, err: true},
+		{rate: "abc", buy: false, err: true},
+	}
+
+	for _, test := range tests {
+		price, err := quotePrice(&OrderExchangeRate{Price: test.rate}, test.buy)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %g", test.rate, price)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.rate, err.Error())
+		} else if math.Abs(price-test.expected) > 1e-6 {
+			t.Errorf("%s: expected %g, got %g", test.rate, test.expected, price)
+		}
+	}
+}
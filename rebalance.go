@@ -0,0 +1,302 @@
+package goswyftx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errRebalanceWeights   = errors.New("target weights must be positive and add up to 1")
+	errRebalanceThreshold = errors.New("drift threshold can not be negative")
+)
+
+// RebalanceConfig is the allocation a portfolio is rebalanced towards
+type RebalanceConfig struct {
+	// Targets are the weights to hold each asset at keyed by asset code, e.g. 0.5 BTC, 0.3 ETH
+	// and 0.2 AUD. They must add up to 1, assets held but not listed have a target of zero
+	Targets map[string]float64
+	// Threshold is how far an asset's weight can drift from its target before it is traded,
+	// e.g. 0.05 leaves a 50% target alone between 45% and 55%
+	Threshold float64
+	// FeeRate is held back from sell proceeds when working out what can be bought, it defaults
+	// to DefaultFeeRate
+	FeeRate float64
+}
+
+// RebalanceTrade is a single order in a rebalance plan
+type RebalanceTrade struct {
+	Code string
+	Buy  bool
+	// Quantity of the asset to buy or sell
+	Quantity float64
+	// Price of one unit of the asset in the portfolio currency
+	Price float64
+	// Value of the trade in the portfolio currency
+	Value float64
+	// Weight the asset currently makes up of the portfolio and the weight it is traded towards
+	Weight       float64
+	TargetWeight float64
+}
+
+// RebalancePlan is the set of trades that moves a portfolio to its target weights
+type RebalancePlan struct {
+	Time     time.Time
+	Currency string
+	Total    float64
+	// Trades to make, every sell comes before every buy so the proceeds can fund the buys
+	Trades []*RebalanceTrade
+	// Skipped trades drifted past the threshold but were smaller than the asset's minimum order
+	Skipped []*RebalanceTrade
+}
+
+// RebalancePlan will value the account and work out the trades needed to bring it back to
+// the target weights. Nothing is traded, the plan can be inspected and then given to Rebalance
+func (ps *PortfolioService) RebalancePlan(cfg RebalanceConfig) (*RebalancePlan, error) {
+	snapshot, err := ps.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := ps.client.Market().Registry()
+	if err != nil {
+		return nil, fmt.Errorf("could not get assets: %s", err.Error())
+	}
+
+	var rates map[int]*MarketRate
+	for code := range cfg.Targets {
+		asset, ok := registry.ByCode(code)
+		if !ok {
+			return nil, fmt.Errorf("unknown asset: %s", code)
+		}
+		if snapshot.Holding(asset.ID) != nil {
+			continue
+		}
+
+		// targets that aren't held yet still need a price to be bought
+		holding := &PortfolioHolding{AssetID: asset.ID, Code: asset.Code}
+		if asset.ID != snapshot.CurrencyID && rates == nil {
+			if rates, err = ps.client.Market().Rates(snapshot.CurrencyID); err != nil {
+				return nil, fmt.Errorf("could not get live rates: %s", err.Error())
+			}
+		}
+		if err = ps.price(holding, rates, snapshot.CurrencyID); err != nil {
+			return nil, err
+		}
+		snapshot.Holdings = append(snapshot.Holdings, holding)
+	}
+
+	return NewRebalancePlan(snapshot, registry, cfg)
+}
+
+// NewRebalancePlan will work out the trades needed to bring a snapshot back to the target
+// weights. Every target must have a holding in the snapshot, even if its quantity is zero, so
+// it has a price. Trades are rounded down to the asset's order increment and buys are scaled
+// down if the sell proceeds and cash held can't cover them
+func NewRebalancePlan(snapshot *PortfolioSnapshot, registry *AssetRegistry,
+	cfg RebalanceConfig) (*RebalancePlan, error) {
+	if cfg.Threshold < 0 {
+		return nil, errRebalanceThreshold
+	}
+	feeRate := cfg.FeeRate
+	if feeRate == 0 {
+		feeRate = DefaultFeeRate
+	}
+
+	targets := make(map[string]float64, len(cfg.Targets))
+	var sum float64
+	for code, weight := range cfg.Targets {
+		if weight < 0 {
+			return nil, errRebalanceWeights
+		}
+		targets[strings.ToUpper(code)] = weight
+		sum += weight
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		return nil, errRebalanceWeights
+	}
+
+	plan := &RebalancePlan{
+		Time:     snapshot.Time,
+		Currency: snapshot.Currency,
+		Total:    snapshot.Total,
+	}
+	if snapshot.Total <= 0 {
+		return plan, nil
+	}
+
+	seen := make(map[string]bool)
+	var cash float64
+	var sells, buys []*RebalanceTrade
+	for _, holding := range snapshot.Holdings {
+		code := strings.ToUpper(holding.Code)
+		seen[code] = true
+		if holding.AssetID == snapshot.CurrencyID {
+			cash += holding.Value
+			continue
+		}
+
+		trade := &RebalanceTrade{
+			Code:         code,
+			Price:        holding.Price,
+			Weight:       holding.Value / snapshot.Total,
+			TargetWeight: targets[code],
+		}
+		if math.Abs(trade.Weight-trade.TargetWeight) <= cfg.Threshold {
+			continue
+		}
+		if holding.Price <= 0 {
+			return nil, fmt.Errorf("no price for %s", code)
+		}
+
+		delta := trade.TargetWeight*snapshot.Total - holding.Value
+		trade.Buy = delta > 0
+		if trade.Buy {
+			trade.Quantity = delta / holding.Price
+			buys = append(buys, trade)
+		} else {
+			trade.Quantity = math.Min(-delta/holding.Price, holding.Quantity)
+			sells = append(sells, trade)
+		}
+	}
+	for code := range targets {
+		if !seen[code] {
+			return nil, fmt.Errorf("no holding with a price for %s", code)
+		}
+	}
+
+	for _, trade := range sells {
+		if plan.addTrade(trade, registry) {
+			cash += trade.Value * (1 - feeRate)
+		}
+	}
+
+	var needed float64
+	for _, trade := range buys {
+		needed += trade.Quantity * trade.Price * (1 + feeRate)
+	}
+	scale := 1.0
+	if needed > cash {
+		scale = math.Max(cash, 0) / needed
+	}
+	for _, trade := range buys {
+		trade.Quantity *= scale
+		plan.addTrade(trade, registry)
+	}
+
+	sort.SliceStable(plan.Trades, func(i, j int) bool {
+		if plan.Trades[i].Buy != plan.Trades[j].Buy {
+			return !plan.Trades[i].Buy
+		}
+		return plan.Trades[i].Value > plan.Trades[j].Value
+	})
+
+	return plan, nil
+}
+
+// addTrade will round a trade to its asset's increment and add it to the plan, or to the
+// skipped trades if it is below the minimum order. It returns whether the trade will be made
+func (p *RebalancePlan) addTrade(trade *RebalanceTrade, registry *AssetRegistry) bool {
+	var minOrder float64
+	if asset, ok := registry.ByCode(trade.Code); ok {
+		minOrder, _ = parseFloat(asset.MinimumOrder)
+		if increment := asset.Increment(); increment > 0 {
+			trade.Quantity = math.Floor(trade.Quantity/increment+1e-9) * increment
+		}
+	}
+	trade.Value = trade.Quantity * trade.Price
+
+	if trade.Quantity <= 0 || trade.Quantity < minOrder {
+		p.Skipped = append(p.Skipped, trade)
+		return false
+	}
+	p.Trades = append(p.Trades, trade)
+
+	return true
+}
+
+// Orders will return the market orders that make the plan's trades, sells first
+func (p *RebalancePlan) Orders() []*OrderPlace {
+	orders := make([]*OrderPlace, 0, len(p.Trades))
+	for _, trade := range p.Trades {
+		orders = append(orders, &OrderPlace{
+			Primary:       p.Currency,
+			Secondary:     trade.Code,
+			Quantity:      float32(trade.Quantity),
+			AssetQuantity: trade.Code,
+			OrderType:     marketOrderType(trade.Buy),
+		})
+	}
+
+	return orders
+}
+
+// String will describe the plan's trades one per line for a dry run
+func (p *RebalancePlan) String() string {
+	var b strings.Builder
+	b.WriteString(buildString("portfolio value ", strconv.FormatFloat(p.Total, 'f', 2, 64), " ",
+		p.Currency, "\n"))
+	if len(p.Trades) == 0 {
+		b.WriteString("no trades needed\n")
+	}
+
+	describe := func(action string, trade *RebalanceTrade) {
+		fmt.Fprintf(&b, "%s %s %s @ %s = %s %s (%.2f%% -> %.2f%%)\n", action,
+			strconv.FormatFloat(trade.Quantity, 'f', -1, 64), trade.Code,
+			strconv.FormatFloat(trade.Price, 'f', -1, 64),
+			strconv.FormatFloat(trade.Value, 'f', 2, 64), p.Currency, trade.Weight*100,
+			trade.TargetWeight*100)
+	}
+	for _, trade := range p.Trades {
+		if trade.Buy {
+			describe("BUY", trade)
+		} else {
+			describe("SELL", trade)
+		}
+	}
+	for _, trade := range p.Skipped {
+		describe("SKIP", trade)
+	}
+
+	return b.String()
+}
+
+// Rebalance will place the plan's orders one at a time, every sell before any buy. If a sell
+// fails no buys are placed. A result is returned for every order that was attempted along
+// with a *BatchError if any failed
+func (ps *PortfolioService) Rebalance(ctx context.Context, plan *RebalancePlan,
+	opts ...PlaceOption) ([]*OrderResult, error) {
+	orderService := ps.client.WithContext(ctx).Order()
+
+	var results, failed []*OrderResult
+	sellFailed := false
+	for _, order := range plan.Orders() {
+		if sellFailed && IsBuyOrder(order.OrderType) {
+			break
+		}
+
+		result := &OrderResult{Order: order}
+		if result.Err = ctx.Err(); result.Err == nil {
+			result.OrderID, result.Err = orderService.Place(order, opts...)
+		}
+		results = append(results, result)
+
+		if result.Err != nil {
+			failed = append(failed, result)
+			if !IsBuyOrder(order.OrderType) {
+				sellFailed = true
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, &BatchError{failed}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,132 @@
+package goswyftx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func TestNewRebalancePlan(t *testing.T) {
+	registry := goswyftx.NewAssetRegistry([]*goswyftx.MarketAsset{
+		{ID: 1, Code: "AUD"},
+		{ID: 3, Code: "BTC", MinimumOrder: "0.001", MinimumOrderIncrement: 0.0001},
+		{ID: 5, Code: "ETH", MinimumOrder: "6"},
+	})
+	snapshot := &goswyftx.PortfolioSnapshot{
+		CurrencyID: 1,
+		Currency:   "AUD",
+		Total:      1000,
+		Holdings: []*goswyftx.PortfolioHolding{
+			{AssetID: 3, Code: "BTC", Quantity: 1, Price: 600, Value: 600},
+			{AssetID: 1, Code: "AUD", Quantity: 200, Price: 1, Value: 200},
+			{AssetID: 5, Code: "ETH", Quantity: 10, Price: 20, Value: 200},
+		},
+	}
+	targets := map[string]float64{"BTC": 0.5, "eth": 0.3, "AUD": 0.2}
+
+	plan, err := goswyftx.NewRebalancePlan(snapshot, registry, goswyftx.RebalanceConfig{
+		Targets:   targets,
+		Threshold: 0.05,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// buying 5 ETH is below its minimum order of 6 so only the sell is made
+	if len(plan.Trades) != 1 || len(plan.Skipped) != 1 {
+		t.Fatalf("expected 1 trade and 1 skipped trade, got %d and %d", len(plan.Trades),
+			len(plan.Skipped))
+	}
+	sell := plan.Trades[0]
+	if sell.Buy || sell.Code != "BTC" || math.Abs(sell.Quantity-0.1666) > 1e-6 {
+		t.Errorf("expected to sell 0.1666 BTC, got %+v", sell)
+	}
+	if skipped := plan.Skipped[0]; !skipped.Buy || skipped.Code != "ETH" {
+		t.Errorf("expected an ETH buy to be skipped, got %+v", skipped)
+	}
+
+	plan, err = goswyftx.NewRebalancePlan(snapshot, registry, goswyftx.RebalanceConfig{
+		Targets:   targets,
+		Threshold: 0.15,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Trades) != 0 {
+		t.Errorf("expected no trades within the threshold, got %d", len(plan.Trades))
+	}
+
+	_, err = goswyftx.NewRebalancePlan(snapshot, registry, goswyftx.RebalanceConfig{
+		Targets: map[string]float64{"BTC": 0.5, "ETH": 0.3},
+	})
+	if err == nil {
+		t.Error("expected an error when the weights don't add up to 1")
+	}
+}
+
+func TestRebalancePlanScalesBuys(t *testing.T) {
+	registry := goswyftx.NewAssetRegistry([]*goswyftx.MarketAsset{
+		{ID: 1, Code: "AUD"},
+		{ID: 3, Code: "BTC"},
+		{ID: 5, Code: "ETH"},
+	})
+	snapshot := &goswyftx.PortfolioSnapshot{
+		CurrencyID: 1,
+		Currency:   "AUD",
+		Total:      1000,
+		Holdings: []*goswyftx.PortfolioHolding{
+			{AssetID: 1, Code: "AUD", Quantity: 1000, Price: 1, Value: 1000},
+			{AssetID: 3, Code: "BTC", Price: 500},
+			{AssetID: 5, Code: "ETH", Price: 20},
+		},
+	}
+
+	plan, err := goswyftx.NewRebalancePlan(snapshot, registry, goswyftx.RebalanceConfig{
+		Targets: map[string]float64{"BTC": 0.5, "ETH": 0.5},
+		FeeRate: 0.01,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spent float64
+	for _, trade := range plan.Trades {
+		if !trade.Buy {
+			t.Errorf("expected only buys, got a sell of %s", trade.Code)
+		}
+		spent += trade.Value * 1.01
+	}
+	if len(plan.Trades) != 2 || spent > 1000+1e-9 {
+		t.Errorf("expected 2 buys costing at most 1000 with fees, got %d costing %f",
+			len(plan.Trades), spent)
+	}
+}
+
+func TestRebalancePlanIncrement(t *testing.T) {
+	registry := goswyftx.NewAssetRegistry([]*goswyftx.MarketAsset{
+		{ID: 1, Code: "AUD"},
+		{ID: 3, Code: "BTC", MinimumOrderIncrement: 0.01},
+	})
+	snapshot := &goswyftx.PortfolioSnapshot{
+		CurrencyID: 1,
+		Currency:   "AUD",
+		Total:      1000,
+		Holdings: []*goswyftx.PortfolioHolding{
+			{AssetID: 3, Code: "BTC", Quantity: 1, Price: 1000, Value: 1000},
+			{AssetID: 1, Code: "AUD", Price: 1},
+		},
+	}
+
+	plan, err := goswyftx.NewRebalancePlan(snapshot, registry, goswyftx.RebalanceConfig{
+		Targets: map[string]float64{"BTC": 0.93, "AUD": 0.07},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a float32 increment of 0.01 widened to a float64 would sell 0.0699999998
+	if len(plan.Trades) != 1 || math.Abs(plan.Trades[0].Quantity-0.07) > 1e-12 {
+		t.Errorf("expected to sell 0.07 BTC, got %+v", plan.Trades)
+	}
+}
@@ -0,0 +1,173 @@
+package goswyftx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AssetReconciliation compares the balance of an asset worked out from the ledger with the
+// balance swyftx reports
+type AssetReconciliation struct {
+	Asset string
+	// Computed is the sum of every settled ledger entry
+	Computed float64
+	// Fees are the trade fees taken from the asset, they are estimated for orders that
+	// don't report them
+	Fees float64
+	// Pending is the sum of withdrawals that haven't completed yet
+	Pending float64
+	// Reported is the available balance from AccountService.Balance
+	Reported float64
+	// Difference is the reported balance less the computed and pending balances after fees
+	Difference float64
+	// Entries that make up the computed balance
+	Entries []*LedgerEntry
+	// PendingEntries that make up the pending balance
+	PendingEntries []*LedgerEntry
+}
+
+// Matched reports whether the reported balance matches the ledger within the tolerance used
+// to reconcile
+func (ar *AssetReconciliation) Matched(tolerance float64) bool {
+	return math.Abs(ar.Difference) <= tolerance
+}
+
+// Reconciliation is the result of reconciling every asset
+type Reconciliation struct {
+	Time      time.Time
+	Tolerance float64
+	// Assets in the ledger or balances ordered by code
+	Assets []*AssetReconciliation
+}
+
+// Discrepancies are the assets whose reported balance doesn't match the ledger
+func (r *Reconciliation) Discrepancies() []*AssetReconciliation {
+	var discrepancies []*AssetReconciliation
+	for _, asset := range r.Assets {
+		if !asset.Matched(r.Tolerance) {
+			discrepancies = append(discrepancies, asset)
+		}
+	}
+
+	return discrepancies
+}
+
+// PendingWithdrawals are the assets with withdrawals that haven't completed yet
+func (r *Reconciliation) PendingWithdrawals() []*AssetReconciliation {
+	var pending []*AssetReconciliation
+	for _, asset := range r.Assets {
+		if len(asset.PendingEntries) > 0 {
+			pending = append(pending, asset)
+		}
+	}
+
+	return pending
+}
+
+// Reconcile will build the ledger of the given asset codes, or every asset, and compare it
+// to the account balances. Differences no larger than tolerance are treated as matching
+func (ls *LedgerService) Reconcile(tolerance float64, assetCodes ...string) (*Reconciliation, error) {
+	entries, err := ls.Entries(assetCodes...)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := ls.client.Account().Balance()
+	if err != nil {
+		return nil, fmt.Errorf("could not get balances: %s", err.Error())
+	}
+
+	registry, err := ls.client.Market().Registry()
+	if err != nil {
+		return nil, fmt.Errorf("could not get assets: %s", err.Error())
+	}
+
+	if len(assetCodes) > 0 {
+		wanted := make(map[int]bool)
+		for _, code := range assetCodes {
+			if asset, ok := registry.ByCode(code); ok {
+				wanted[asset.ID] = true
+			}
+		}
+		filtered := balances[:0]
+		for _, balance := range balances {
+			if wanted[balance.AssetID] {
+				filtered = append(filtered, balance)
+			}
+		}
+		balances = filtered
+
+		// trades of the wanted assets also have entries for their counter assets, which
+		// would be reported as discrepancies as the rest of their ledger wasn't fetched
+		filteredEntries := entries[:0]
+		for _, entry := range entries {
+			if asset, ok := registry.ByCode(entry.Asset); ok && wanted[asset.ID] {
+				filteredEntries = append(filteredEntries, entry)
+			}
+		}
+		entries = filteredEntries
+	}
+
+	return Reconcile(entries, balances, registry, tolerance)
+}
+
+// Reconcile will replay ledger entries per asset and compare the result with balances.
+// Pending withdrawals are kept separate from the computed balance, trade fees are taken off
+// the asset they were charged in and failed or cancelled entries are ignored
+func Reconcile(entries []*LedgerEntry, balances []*AccountBalance, registry *AssetRegistry,
+	tolerance float64) (*Reconciliation, error) {
+	byAsset := make(map[string]*AssetReconciliation)
+	get := func(code string) *AssetReconciliation {
+		code = strings.ToUpper(code)
+		ar, ok := byAsset[code]
+		if !ok {
+			ar = &AssetReconciliation{Asset: code}
+			byAsset[code] = ar
+		}
+		return ar
+	}
+
+	for _, entry := range entries {
+		switch {
+		case ClassifyHistoryStatus(entry.Status) == HistoryStatusFailed:
+			continue
+		case entry.Kind == LedgerWithdrawal &&
+			ClassifyHistoryStatus(entry.Status) == HistoryStatusPending:
+			ar := get(entry.Asset)
+			ar.Pending += entry.Quantity
+			ar.PendingEntries = append(ar.PendingEntries, entry)
+		default:
+			ar := get(entry.Asset)
+			ar.Computed += entry.Quantity
+			ar.Fees += entry.Fee
+			ar.Entries = append(ar.Entries, entry)
+		}
+	}
+
+	for _, balance := range balances {
+		asset, ok := registry.ByID(balance.AssetID)
+		if !ok {
+			return nil, fmt.Errorf("unknown asset: %d", balance.AssetID)
+		}
+
+		reported, err := parseFloat(balance.AvailableBalance)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse balance of %s: %s", asset.Code, err.Error())
+		}
+		get(asset.Code).Reported = reported
+	}
+
+	reconciliation := &Reconciliation{Time: time.Now(), Tolerance: tolerance}
+	for _, ar := range byAsset {
+		ar.Difference = ar.Reported - (ar.Computed - ar.Fees) - ar.Pending
+		reconciliation.Assets = append(reconciliation.Assets, ar)
+	}
+	sort.Slice(reconciliation.Assets, func(i, j int) bool {
+		return reconciliation.Assets[i].Asset < reconciliation.Assets[j].Asset
+	})
+
+	return reconciliation, nil
+}
@@ -0,0 +1,99 @@
+package goswyftx_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func TestReconcile(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	registry := goswyftx.NewAssetRegistry([]*goswyftx.MarketAsset{
+		{ID: 1, Code: "AUD"}, {ID: 3, Code: "BTC"}, {ID: 5, Code: "ETH"},
+	})
+
+	entries := []*goswyftx.LedgerEntry{
+		{Time: start, Asset: "AUD", Quantity: 1000, Kind: goswyftx.LedgerDeposit,
+			Status: "Completed"},
+		// a buy of 2 BTC at 100, the fee is only on the AUD side
+		{Time: start.Add(time.Hour), Asset: "BTC", Quantity: 2, Kind: goswyftx.LedgerBuy,
+			Status: goswyftx.OrderStatusCompleted, Reference: "20"},
+		{Time: start.Add(time.Hour), Asset: "AUD", Quantity: -200, Fee: 1.2,
+			Kind: goswyftx.LedgerSell, Status: goswyftx.OrderStatusCompleted, Reference: "20"},
+		{Time: start.Add(2 * time.Hour), Asset: "btc", Quantity: -0.5,
+			Kind: goswyftx.LedgerWithdrawal, Status: "Pending"},
+		{Time: start.Add(3 * time.Hour), Asset: "BTC", Quantity: -1,
+			Kind: goswyftx.LedgerWithdrawal, Status: "Failed"},
+		{Time: start, Asset: "ETH", Quantity: 3, Kind: goswyftx.LedgerDeposit,
+			Status: "Completed"},
+	}
+	balances := []*goswyftx.AccountBalance{
+		{AssetID: 1, AvailableBalance: "798.8"},
+		{AssetID: 3, AvailableBalance: "1.5"},
+		{AssetID: 5, AvailableBalance: "2"},
+	}
+
+	reconciliation, err := goswyftx.Reconcile(entries, balances, registry, 1e-9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		asset                             string
+		computed, fees, pending, reported float64
+		entries, pendingEntries           int
+		matched                           bool
+	}{
+		{"AUD", 800, 1.2, 0, 798.8, 2, 0, true},
+		{"BTC", 2, 0, -0.5, 1.5, 1, 1, true},
+		{"ETH", 3, 0, 0, 2, 1, 0, false},
+	}
+	if len(reconciliation.Assets) != len(expected) {
+		t.Fatalf("expected %d assets, got %d", len(expected), len(reconciliation.Assets))
+	}
+	for i, e := range expected {
+		ar := reconciliation.Assets[i]
+		if ar.Asset != e.asset {
+			t.Errorf("%d: expected %s, got %s", i, e.asset, ar.Asset)
+			continue
+		}
+		if math.Abs(ar.Computed-e.computed) > 1e-9 || math.Abs(ar.Fees-e.fees) > 1e-9 ||
+			math.Abs(ar.Pending-e.pending) > 1e-9 || math.Abs(ar.Reported-e.reported) > 1e-9 {
+			t.Errorf("%s: expected computed %g, fees %g, pending %g and reported %g, got %+v",
+				e.asset, e.computed, e.fees, e.pending, e.reported, ar)
+		}
+		if len(ar.Entries) != e.entries || len(ar.PendingEntries) != e.pendingEntries {
+			t.Errorf("%s: expected %d entries and %d pending, got %d and %d", e.asset,
+				e.entries, e.pendingEntries, len(ar.Entries), len(ar.PendingEntries))
+		}
+		if ar.Matched(reconciliation.Tolerance) != e.matched {
+			t.Errorf("%s: expected matched to be %t, difference %g", e.asset, e.matched,
+				ar.Difference)
+		}
+	}
+
+	if discrepancies := reconciliation.Discrepancies(); len(discrepancies) != 1 ||
+		discrepancies[0].Asset != "ETH" || math.Abs(discrepancies[0].Difference+1) > 1e-9 {
+		t.Errorf("expected ETH to be 1 short, got %+v", discrepancies)
+	}
+	if pending := reconciliation.PendingWithdrawals(); len(pending) != 1 ||
+		pending[0].Asset != "BTC" {
+		t.Errorf("expected BTC to have a pending withdrawal, got %+v", pending)
+	}
+}
+
+func TestReconcileBalanceErrors(t *testing.T) {
+	registry := goswyftx.NewAssetRegistry([]*goswyftx.MarketAsset{{ID: 1, Code: "AUD"}})
+
+	tests := map[string][]*goswyftx.AccountBalance{
+		"unknown asset":      {{AssetID: 99, AvailableBalance: "1"}},
+		"unparsable balance": {{AssetID: 1, AvailableBalance: "lots"}},
+	}
+	for name, balances := range tests {
+		if _, err := goswyftx.Reconcile(nil, balances, registry, 0); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package goswyftx
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// registryCacheTTL is how long a cached registry is used before the assets are fetched again
+const registryCacheTTL = time.Hour
+
+// AssetRegistry looks up market assets by their ID or code
+type AssetRegistry struct {
+	assets []*MarketAsset
+	byID   map[int]*MarketAsset
+	byCode map[string]*MarketAsset
+}
+
+// NewAssetRegistry will create a registry from a list of assets, usually the result of
+// MarketService.Assets
+func NewAssetRegistry(assets []*MarketAsset) *AssetRegistry {
+	r := &AssetRegistry{
+		assets: assets,
+		byID:   make(map[int]*MarketAsset, len(assets)),
+		byCode: make(map[string]*MarketAsset, len(assets)),
+	}
+	for _, asset := range assets {
+		r.byID[asset.ID] = asset
+		r.byCode[strings.ToUpper(asset.Code)] = asset
+	}
+
+	return r
+}
+
+// Registry will fetch every market asset and return a registry of them
+func (ms *MarketService) Registry() (*AssetRegistry, error) {
+	assets, err := ms.Assets()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAssetRegistry(assets), nil
+}
+
+// registryCache holds the last registry fetched, a nil cache always fetches
+type registryCache struct {
+	mu       sync.Mutex
+	registry *AssetRegistry
+	fetched  time.Time
+}
+
+func (rc *registryCache) get(fetch func() (*AssetRegistry, error)) (*AssetRegistry, error) {
+	if rc == nil {
+		return fetch()
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.registry != nil && time.Since(rc.fetched) < registryCacheTTL {
+		return rc.registry, nil
+	}
+	registry, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	rc.registry, rc.fetched = registry, time.Now()
+
+	return registry, nil
+}
+
+// registry will return the client's cached registry, fetching it if it is missing or stale
+func (c *Client) registry() (*AssetRegistry, error) {
+	return c.assets.get(c.Market().Registry)
+}
+
+// Assets in the registry
+func (r *AssetRegistry) Assets() []*MarketAsset {
+	return r.assets
+}
+
+// ByID will look up an asset given its ID
+func (r *AssetRegistry) ByID(id int) (*MarketAsset, bool) {
+	asset, ok := r.byID[id]
+	return asset, ok
+}
+
+// ByCode will look up an asset given its code, the code is case insensitive
+func (r *AssetRegistry) ByCode(code string) (*MarketAsset, bool) {
+	asset, ok := r.byCode[strings.ToUpper(code)]
+	return asset, ok
+}
@@ -0,0 +1,72 @@
+package goswyftx
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistryCache(t *testing.T) {
+	fetches := 0
+	fetch := func() (*AssetRegistry, error) {
+		fetches++
+		return NewAssetRegistry([]*MarketAsset{{ID: 3, Code: "BTC"}}), nil
+	}
+
+	cache := new(registryCache)
+	for i := 0; i < 3; i++ {
+		registry, err := cache.get(fetch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := registry.ByCode("btc"); !ok {
+			t.Fatal("expected the registry to have BTC")
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected the registry to be fetched once, got %d", fetches)
+	}
+
+	cache.fetched = time.Now().Add(-registryCacheTTL)
+	if _, err := cache.get(fetch); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Errorf("expected a stale registry to be fetched again, got %d fetches", fetches)
+	}
+
+	// a failed fetch isn't cached
+	cache = new(registryCache)
+	if _, err := cache.get(func() (*AssetRegistry, error) {
+		return nil, errors.New("down")
+	}); err == nil {
+		t.Error("expected the fetch error")
+	}
+	if cache.registry != nil {
+		t.Error("expected nothing to be cached")
+	}
+
+	var none *registryCache
+	if _, err := none.get(fetch); err != nil || fetches != 3 {
+		t.Errorf("expected a nil cache to always fetch, got %v after %d fetches", err, fetches)
+	}
+}
+
+func TestMarketAssetIncrement(t *testing.T) {
+	tests := []struct {
+		increment float32
+		want      float64
+	}{
+		{0, 0},
+		{0.01, 0.01},
+		{0.00000001, 0.00000001},
+		{1, 1},
+	}
+
+	for _, test := range tests {
+		asset := &MarketAsset{MinimumOrderIncrement: test.increment}
+		if got := asset.Increment(); got != test.want {
+			t.Errorf("%g: expected an increment of %g, got %.12g", test.increment, test.want, got)
+		}
+	}
+}
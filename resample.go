@@ -0,0 +1,201 @@
+package goswyftx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	errResolution = errors.New("resolution must be greater than zero")
+	errBarTime    = errors.New("bar is missing a time")
+)
+
+// ParseResolution will convert a swyftx chart resolution such as "1m", "15m", "1h", "4h", "1d"
+// or "1w" into a duration
+func ParseResolution(resolution string) (time.Duration, error) {
+	if len(resolution) < 2 {
+		return 0, errors.New("invalid resolution: " + resolution)
+	}
+
+	n, err := strconv.Atoi(resolution[:len(resolution)-1])
+	if err != nil || n <= 0 {
+		return 0, errors.New("invalid resolution: " + resolution)
+	}
+
+	var unit time.Duration
+	switch resolution[len(resolution)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, errors.New("invalid resolution: " + resolution)
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+// mondayEpochDay is the first Monday after the unix epoch as days since the epoch
+const mondayEpochDay = 4
+
+// BarStart will return the start of the interval that t falls into. Intervals are aligned to
+// midnight in loc, so daily bars begin at local midnight rather than midnight UTC. Intervals of
+// whole weeks begin on a Monday, other intervals of more than a day are counted from the unix
+// epoch
+func BarStart(t time.Time, interval time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+
+	year, month, day := t.Date()
+	if interval < 24*time.Hour {
+		midnight := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		return midnight.Add(t.Sub(midnight).Truncate(interval))
+	}
+
+	// count calendar days rather than elapsed time so daylight saving changes don't shift
+	// the boundary away from midnight
+	days := int64(interval / (24 * time.Hour))
+	var anchor int64
+	if days%7 == 0 {
+		anchor = mondayEpochDay
+	}
+	epochDay := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+	since := epochDay - anchor
+	// floor rather than truncate so days before the anchor go to the interval before it
+	if offset := since % days; offset < 0 {
+		since -= offset + days
+	} else {
+		since -= offset
+	}
+
+	return time.Date(1970, time.January, 1+int(anchor+since), 0, 0, 0, 0, loc)
+}
+
+// Resample will aggregate bars into coarser bars of the given interval. The open of each
+// resampled bar is the open of the first bar in the interval, the close is the close of the
+// last, the high and low are the extremes across the interval and the volume is the sum.
+// Bars do not need to be sorted, the result is sorted oldest first
+func Resample(bars []*OCHLVT, interval time.Duration, loc *time.Location) ([]*OCHLVT, error) {
+	if interval <= 0 {
+		return nil, errResolution
+	}
+
+	sorted, err := sortBars(bars)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		resampled         []*OCHLVT
+		current           *OCHLVT
+		currHigh, currLow float64
+	)
+	for _, bar := range sorted {
+		high, err := strconv.ParseFloat(bar.High, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse bar high: %s", err.Error())
+		}
+		low, err := strconv.ParseFloat(bar.Low, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse bar low: %s", err.Error())
+		}
+
+		start := BarStart(bar.Time.Time, interval, loc)
+		if current == nil || !current.Time.Equal(start) {
+			current = &OCHLVT{
+				Time:   SwyftxTime{start},
+				Open:   bar.Open,
+				High:   bar.High,
+				Low:    bar.Low,
+				Close:  bar.Close,
+				Volume: bar.Volume,
+			}
+			currHigh, currLow = high, low
+			resampled = append(resampled, current)
+			continue
+		}
+
+		// keep the original strings so no precision is lost converting back from a float
+		if high > currHigh {
+			current.High, currHigh = bar.High, high
+		}
+		if low < currLow {
+			current.Low, currLow = bar.Low, low
+		}
+		current.Close = bar.Close
+		current.Volume += bar.Volume
+	}
+
+	return resampled, nil
+}
+
+// FillGaps will insert flat bars for any interval between the first and last bar that has no
+// data. A synthesised bar opens, closes, highs and lows at the previous close with no volume
+func FillGaps(bars []*OCHLVT, interval time.Duration, loc *time.Location) ([]*OCHLVT, error) {
+	if interval <= 0 {
+		return nil, errResolution
+	}
+
+	sorted, err := sortBars(bars)
+	if err != nil {
+		return nil, err
+	}
+
+	var filled []*OCHLVT
+	for i, bar := range sorted {
+		if i > 0 {
+			prev := filled[len(filled)-1]
+			start := BarStart(bar.Time.Time, interval, loc)
+			for next := nextBarStart(prev.Time.Time, interval, loc); next.Before(start); next = nextBarStart(next, interval, loc) {
+				filled = append(filled, &OCHLVT{
+					Time:  SwyftxTime{next},
+					Open:  prev.Close,
+					High:  prev.Close,
+					Low:   prev.Close,
+					Close: prev.Close,
+				})
+			}
+		}
+		filled = append(filled, bar)
+	}
+
+	return filled, nil
+}
+
+func nextBarStart(t time.Time, interval time.Duration, loc *time.Location) time.Time {
+	start := BarStart(t, interval, loc)
+	if interval < 24*time.Hour {
+		return BarStart(start.Add(interval), interval, loc)
+	}
+
+	days := int(interval / (24 * time.Hour))
+	return BarStart(start.AddDate(0, 0, days), interval, loc)
+}
+
+func sortBars(bars []*OCHLVT) ([]*OCHLVT, error) {
+	sorted := make([]*OCHLVT, 0, len(bars))
+	for _, bar := range bars {
+		if bar == nil {
+			continue
+		}
+		if bar.Time.IsZero() {
+			return nil, errBarTime
+		}
+		sorted = append(sorted, bar)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time.Time)
+	})
+
+	return sorted, nil
+}
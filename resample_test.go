@@ -0,0 +1,153 @@
+package goswyftx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+func bar(t time.Time, open, high, low, close string, volume int) *goswyftx.OCHLVT {
+	return &goswyftx.OCHLVT{
+		Time:   goswyftx.SwyftxTime{Time: t},
+		Open:   open,
+		High:   high,
+		Low:    low,
+		Close:  close,
+		Volume: volume,
+	}
+}
+
+func TestResample(t *testing.T) {
+	start := time.Date(2020, time.June, 1, 10, 0, 0, 0, time.UTC)
+	bars := []*goswyftx.OCHLVT{
+		bar(start.Add(5*time.Minute), "10.5", "12.25", "10", "11", 3),
+		bar(start, "10", "11", "9.5", "10.5", 2),
+		bar(start.Add(10*time.Minute), "11", "11.5", "8.75", "9", 1),
+		bar(start.Add(15*time.Minute), "9", "9.5", "9", "9.25", 4),
+	}
+
+	resampled, err := goswyftx.Resample(bars, 15*time.Minute, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resampled) != 2 {
+		t.Fatalf("expected 2 bars, got %d", len(resampled))
+	}
+
+	first := resampled[0]
+	if !first.Time.Equal(start) || first.Open != "10" || first.High != "12.25" ||
+		first.Low != "8.75" || first.Close != "9" || first.Volume != 6 {
+		t.Errorf("unexpected first bar: %+v", first)
+	}
+
+	if resampled[1].Open != "9" || resampled[1].Volume != 4 {
+		t.Errorf("unexpected second bar: %+v", resampled[1])
+	}
+}
+
+func TestResampleDailyTimezone(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skip("timezone data unavailable:", err)
+	}
+
+	// 13:00 and 15:00 UTC straddle midnight in Sydney (UTC+10 in June)
+	bars := []*goswyftx.OCHLVT{
+		bar(time.Date(2020, time.June, 1, 13, 0, 0, 0, time.UTC), "1", "1", "1", "1", 1),
+		bar(time.Date(2020, time.June, 1, 15, 0, 0, 0, time.UTC), "2", "2", "2", "2", 1),
+	}
+
+	utc, err := goswyftx.Resample(bars, 24*time.Hour, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utc) != 1 {
+		t.Errorf("expected 1 UTC daily bar, got %d", len(utc))
+	}
+
+	local, err := goswyftx.Resample(bars, 24*time.Hour, sydney)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(local) != 2 {
+		t.Fatalf("expected 2 Sydney daily bars, got %d", len(local))
+	}
+	if want := time.Date(2020, time.June, 2, 0, 0, 0, 0, sydney); !local[1].Time.Equal(want) {
+		t.Errorf("expected second bar to start at %s, got %s", want, local[1].Time)
+	}
+}
+
+func TestFillGaps(t *testing.T) {
+	start := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+	bars := []*goswyftx.OCHLVT{
+		bar(start, "1", "2", "1", "2", 5),
+		bar(start.Add(3*time.Minute), "3", "3", "3", "3", 1),
+	}
+
+	filled, err := goswyftx.FillGaps(bars, time.Minute, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(filled) != 4 {
+		t.Fatalf("expected 4 bars, got %d", len(filled))
+	}
+	for _, b := range filled[1:3] {
+		if b.Open != "2" || b.Close != "2" || b.Volume != 0 {
+			t.Errorf("unexpected synthesised bar: %+v", b)
+		}
+	}
+	if !filled[2].Time.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("unexpected gap time: %s", filled[2].Time)
+	}
+}
+
+func TestBarStartWeekly(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	tests := []struct {
+		t        time.Time
+		interval time.Duration
+		expected time.Time
+	}{
+		// Wednesday the 3rd of June 2020 is in the week starting Monday the 1st
+		{time.Date(2020, time.June, 3, 15, 0, 0, 0, time.UTC), week,
+			time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC), week,
+			time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2020, time.May, 31, 23, 59, 0, 0, time.UTC), week,
+			time.Date(2020, time.May, 25, 0, 0, 0, 0, time.UTC)},
+		// fortnights start on a Monday too
+		{time.Date(2020, time.June, 10, 0, 0, 0, 0, time.UTC), 2 * week,
+			time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		// days before the first Monday after the epoch
+		{time.Date(1970, time.January, 2, 0, 0, 0, 0, time.UTC), week,
+			time.Date(1969, time.December, 29, 0, 0, 0, 0, time.UTC)},
+		// other multi-day intervals are counted from the epoch
+		{time.Date(1970, time.January, 5, 0, 0, 0, 0, time.UTC), 3 * 24 * time.Hour,
+			time.Date(1970, time.January, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		start := goswyftx.BarStart(test.t, test.interval, time.UTC)
+		if !start.Equal(test.expected) {
+			t.Errorf("%s every %s: expected %s, got %s", test.t, test.interval, test.expected,
+				start)
+		}
+		if start.Weekday() != time.Monday && test.interval%week == 0 {
+			t.Errorf("%s: expected the week to start on a Monday, got %s", test.t,
+				start.Weekday())
+		}
+	}
+
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skip("timezone data unavailable:", err)
+	}
+	// 3pm Sunday UTC is already Monday in Sydney
+	start := goswyftx.BarStart(time.Date(2020, time.May, 31, 15, 0, 0, 0, time.UTC), week, sydney)
+	if expected := time.Date(2020, time.June, 1, 0, 0, 0, 0, sydney); !start.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, start)
+	}
+}
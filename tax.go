@@ -0,0 +1,491 @@
+package goswyftx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaxCurrency is the currency tax reports are valued in
+const TaxCurrency = "AUD"
+
+// chartPriceResolution is the size of the bars ChartPriceSource prices events with
+const chartPriceResolution = time.Hour
+
+// ChartPriceSource is a PriceSource that looks up historical prices from hourly bars. A day of
+// bars is fetched at a time and cached so repeated lookups don't use up the rate limit
+type ChartPriceSource struct {
+	chart *ChartService
+	quote string
+	cache map[string][]*OCHLVT
+}
+
+// NewChartPriceSource will create a price source that prices assets in the quote asset
+func NewChartPriceSource(chart *ChartService, quote string) *ChartPriceSource {
+	return &ChartPriceSource{chart: chart, quote: quote, cache: make(map[string][]*OCHLVT)}
+}
+
+// Price will return the close of the hourly bar that contains at
+func (s *ChartPriceSource) Price(asset string, at time.Time) (float64, error) {
+	if strings.EqualFold(asset, s.quote) {
+		return 1, nil
+	}
+
+	day := at.UTC().Truncate(24 * time.Hour)
+	key := buildString(strings.ToUpper(asset), "/", day.Format("2006-01-02"))
+	bars, ok := s.cache[key]
+	if !ok {
+		var err error
+		bars, err = s.chart.Bar(&GetBarChartRequest{
+			BaseAsset:      s.quote,
+			SecondaryAsset: asset,
+			Resolution:     "1h",
+			From:           day,
+			To:             day.Add(24 * time.Hour),
+		})
+		if err != nil {
+			return 0, err
+		}
+		s.cache[key] = bars
+	}
+
+	for _, bar := range bars {
+		if bar == nil {
+			continue
+		}
+		// bar times are decoded from a float32 so they can be a minute or so off the hour
+		start := bar.Time.Round(chartPriceResolution)
+		if start.After(at) || !at.Before(start.Add(chartPriceResolution)) {
+			continue
+		}
+
+		price, err := parseFloat(bar.Close)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse price of %s: %s", asset, err.Error())
+		}
+		return price, nil
+	}
+
+	return 0, fmt.Errorf("no price for %s at %s", asset, at.UTC().Format(time.RFC3339))
+}
+
+// Transfer is a deposit or withdrawal of an asset
+type Transfer struct {
+	Time  time.Time
+	Asset string
+	// Quantity is positive for a deposit and negative for a withdrawal
+	Quantity  float64
+	Reference string
+}
+
+// TransfersFromHistory will convert an asset's deposit and withdrawal history into transfers.
+// Events that failed, were cancelled or are still pending are skipped as they haven't moved
+// the asset
+func TransfersFromHistory(asset string, deposits, withdrawals []*CurrencyHistory) ([]*Transfer,
+	error) {
+	var transfers []*Transfer
+	add := func(history []*CurrencyHistory, sign float64) error {
+		for _, h := range history {
+			if h == nil {
+				continue
+			}
+			if status := ClassifyHistoryStatus(h.Status); status == HistoryStatusFailed ||
+				status == HistoryStatusPending {
+				continue
+			}
+			quantity, err := parseFloat(h.Quantity)
+			if err != nil {
+				return fmt.Errorf("could not parse quantity of %s transfer %d: %s", asset, h.ID,
+					err.Error())
+			}
+			transfers = append(transfers, &Transfer{
+				Time:      h.Time.Time,
+				Asset:     strings.ToUpper(asset),
+				Quantity:  sign * absFloat(quantity),
+				Reference: strconv.Itoa(h.ID),
+			})
+		}
+		return nil
+	}
+	if err := add(deposits, 1); err != nil {
+		return nil, err
+	}
+	if err := add(withdrawals, -1); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].Time.Before(transfers[j].Time)
+	})
+
+	return transfers, nil
+}
+
+// CGTEvent is the disposal of a single lot of an asset
+type CGTEvent struct {
+	Asset    string
+	Acquired time.Time
+	Disposed time.Time
+	Quantity float64
+	Proceeds float64
+	Cost     float64
+	Gain     float64
+	// Discountable is set when the lot was held for at least 12 months before disposal, not
+	// counting the days it was acquired and disposed of in Sydney
+	Discountable bool
+	Reference    string
+}
+
+// TaxYearReport is the capital gains of an Australian financial year, which runs from July to
+// June. Every value is in AUD
+type TaxYearReport struct {
+	// Year the financial year ends in, 2021 is July 2020 to June 2021
+	Year   int
+	Events []*CGTEvent
+	// Gains from lots held for less than 12 months
+	Gains float64
+	// DiscountableGains from lots held for 12 months or more, before the discount
+	DiscountableGains float64
+	// Losses in the year as a positive number
+	Losses float64
+	// LossesBroughtForward from earlier years as a positive number
+	LossesBroughtForward float64
+	// NetCapitalGain after applying losses and the 50% discount
+	NetCapitalGain float64
+	// LossesCarriedForward to the next year as a positive number
+	LossesCarriedForward float64
+}
+
+// TaxReporter builds Australian capital gains tax reports from trades and transfers. Trades
+// quoted in anything other than AUD are crypto to crypto swaps, they are treated as a disposal
+// of the quote asset and valued in AUD using prices. The fee of a swap is paid in the quote
+// asset, it reduces the proceeds of whichever asset was disposed of and the quantity of the
+// quote asset.
+//
+// Transfers are not taxable. A withdrawal sets its lots aside and a later deposit of the same
+// asset takes them back, keeping their original cost and acquisition date. A deposit with no
+// lots set aside is treated as acquired at its market value at the time of the deposit
+type TaxReporter struct {
+	prices PriceSource
+	method LotMethod
+}
+
+// NewTaxReporter will create a tax reporter that values events using prices, which must quote
+// prices in AUD. Lots are matched using method
+func NewTaxReporter(prices PriceSource, method LotMethod) *TaxReporter {
+	return &TaxReporter{prices: prices, method: method}
+}
+
+type taxEvent struct {
+	time     time.Time
+	trade    *Trade
+	transfer *Transfer
+}
+
+// Reports will process every trade and transfer in time order and return a report for each
+// financial year with a disposal, oldest first
+func (tr *TaxReporter) Reports(trades []*Trade, transfers []*Transfer) ([]*TaxYearReport, error) {
+	events := make([]taxEvent, 0, len(trades)+len(transfers))
+	for _, trade := range trades {
+		events = append(events, taxEvent{time: trade.Time, trade: trade})
+	}
+	for _, transfer := range transfers {
+		events = append(events, taxEvent{time: transfer.Time, transfer: transfer})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].time.Before(events[j].time)
+	})
+
+	cb := NewCostBasis(tr.method)
+	transferred := make(map[string][]*Lot)
+	for _, event := range events {
+		var err error
+		if event.trade != nil {
+			err = tr.addTrade(cb, event.trade)
+		} else {
+			err = tr.addTransfer(cb, transferred, event.transfer)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tr.reports(cb.Disposals()), nil
+}
+
+func (tr *TaxReporter) addTrade(cb *CostBasis, trade *Trade) error {
+	if strings.EqualFold(trade.Asset, TaxCurrency) {
+		return nil
+	}
+
+	quote := TaxCurrency
+	if !isEmptyStr(trade.Quote) {
+		quote = strings.ToUpper(trade.Quote)
+	}
+
+	// value the trade in AUD
+	rate := 1.0
+	if quote != TaxCurrency {
+		var err error
+		if rate, err = tr.prices.Price(quote, trade.Time); err != nil {
+			return fmt.Errorf("could not get price of %s: %s", quote, err.Error())
+		}
+	}
+	audTrade := *trade
+	audTrade.Price *= rate
+	audTrade.Fee *= rate
+	audTrade.Quote = TaxCurrency
+
+	// buying with a crypto disposes of the quote asset, so the fee comes off its proceeds
+	// rather than adding to the cost of what was bought
+	var quoteFee float64
+	if quote != TaxCurrency && trade.Quantity > 0 {
+		quoteFee, audTrade.Fee = audTrade.Fee, 0
+	}
+	if err := cb.Add(&audTrade); err != nil {
+		return err
+	}
+
+	if quote == TaxCurrency {
+		return nil
+	}
+
+	// the other side of a swap, buying with a crypto disposes of it and selling for a crypto
+	// acquires it, valued at its AUD price. The fee is paid in the quote asset so a buy also
+	// disposes of the fee and a sell only acquires what is left after it
+	quoteQuantity := -trade.Quantity*trade.Price - trade.Fee
+	if quoteQuantity == 0 {
+		return nil
+	}
+	return cb.Add(&Trade{
+		Time:      trade.Time,
+		Asset:     quote,
+		Quantity:  quoteQuantity,
+		Price:     rate,
+		Fee:       quoteFee,
+		Quote:     TaxCurrency,
+		Reference: trade.Reference,
+	})
+}
+
+func (tr *TaxReporter) addTransfer(cb *CostBasis, transferred map[string][]*Lot,
+	transfer *Transfer) error {
+	asset := strings.ToUpper(transfer.Asset)
+	if asset == TaxCurrency || transfer.Quantity == 0 {
+		return nil
+	}
+
+	if transfer.Quantity < 0 {
+		lots, err := cb.match(asset, -transfer.Quantity)
+		if err != nil {
+			return fmt.Errorf("could not withdraw %g %s at %s: %s", -transfer.Quantity, asset,
+				transfer.Time.Format(time.RFC3339), err.Error())
+		}
+		transferred[asset] = append(transferred[asset], lots...)
+		return nil
+	}
+
+	quantity := transfer.Quantity
+	for len(transferred[asset]) > 0 && quantity > lotEpsilon {
+		lot := transferred[asset][0]
+		if lot.Quantity > quantity {
+			cost := lot.Cost * quantity / lot.Quantity
+			cb.lots[asset] = append(cb.lots[asset], &Lot{asset, lot.Acquired, quantity, cost})
+			lot.Quantity -= quantity
+			lot.Cost -= cost
+			quantity = 0
+			break
+		}
+		cb.lots[asset] = append(cb.lots[asset], lot)
+		transferred[asset] = transferred[asset][1:]
+		quantity -= lot.Quantity
+	}
+	if quantity <= lotEpsilon {
+		sortLots(cb.lots[asset])
+		return nil
+	}
+
+	price, err := tr.prices.Price(asset, transfer.Time)
+	if err != nil {
+		return fmt.Errorf("could not get price of %s: %s", asset, err.Error())
+	}
+	if err = cb.Add(&Trade{
+		Time:      transfer.Time,
+		Asset:     asset,
+		Quantity:  quantity,
+		Price:     price,
+		Quote:     TaxCurrency,
+		Reference: transfer.Reference,
+	}); err != nil {
+		return err
+	}
+	sortLots(cb.lots[asset])
+
+	return nil
+}
+
+// sortLots keeps returned lots in acquisition order so FIFO and LIFO still match correctly
+func sortLots(lots []*Lot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lots[i].Acquired.Before(lots[j].Acquired)
+	})
+}
+
+func (tr *TaxReporter) reports(disposals []*Disposal) []*TaxYearReport {
+	byYear := make(map[int]*TaxYearReport)
+	for _, disposal := range disposals {
+		for _, lot := range disposal.Lots {
+			proceeds := disposal.Proceeds * lot.Quantity / disposal.Quantity
+			event := &CGTEvent{
+				Asset:        disposal.Asset,
+				Acquired:     lot.Acquired,
+				Disposed:     disposal.Time,
+				Quantity:     lot.Quantity,
+				Proceeds:     proceeds,
+				Cost:         lot.Cost,
+				Gain:         proceeds - lot.Cost,
+				Discountable: heldOverYear(lot.Acquired, disposal.Time),
+				Reference:    disposal.Reference,
+			}
+
+			year := FinancialYear(disposal.Time)
+			report, ok := byYear[year]
+			if !ok {
+				report = &TaxYearReport{Year: year}
+				byYear[year] = report
+			}
+			report.Events = append(report.Events, event)
+
+			switch {
+			case event.Gain < 0:
+				report.Losses -= event.Gain
+			case event.Discountable:
+				report.DiscountableGains += event.Gain
+			default:
+				report.Gains += event.Gain
+			}
+		}
+	}
+
+	reports := make([]*TaxYearReport, 0, len(byYear))
+	for _, report := range byYear {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Year < reports[j].Year
+	})
+
+	var carried float64
+	for _, report := range reports {
+		report.LossesBroughtForward = carried
+
+		// losses are applied to gains that can't be discounted first, then the discount is
+		// applied to what's left of the discountable gains
+		losses := report.Losses + carried
+		gains := report.Gains - losses
+		discountable := report.DiscountableGains
+		if gains < 0 {
+			discountable += gains
+			gains = 0
+		}
+		if discountable < 0 {
+			carried = -discountable
+			discountable = 0
+		} else {
+			carried = 0
+		}
+
+		report.NetCapitalGain = gains + discountable/2
+		report.LossesCarriedForward = carried
+	}
+
+	return reports
+}
+
+// financialYearLocation is the timezone financial years are split in
+var financialYearLocation = func() *time.Location {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		return time.FixedZone("AEST", 10*60*60)
+	}
+	return loc
+}()
+
+// heldOverYear reports whether an asset was held for at least 12 months, which the ATO counts
+// in whole days excluding the days it was acquired and disposed of. The days are calendar
+// dates in Sydney so the time of day and timezone of either event don't matter
+func heldOverYear(acquired, disposed time.Time) bool {
+	date := func(t time.Time) time.Time {
+		y, m, d := t.In(financialYearLocation).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	// 12 months from the day after the acquisition must have passed by the day before the
+	// disposal
+	held := date(acquired).AddDate(0, 0, 1).AddDate(1, 0, 0)
+	return !date(disposed).Before(held)
+}
+
+// FinancialYear will return the Australian financial year t falls in, named after the year
+// it ends in
+func FinancialYear(t time.Time) int {
+	t = t.In(financialYearLocation)
+	if t.Month() >= time.July {
+		return t.Year() + 1
+	}
+
+	return t.Year()
+}
+
+// WriteCSV will write every CGT event in the report as CSV, dates are written in Sydney
+func (r *TaxYearReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"asset", "acquired", "disposed", "quantity", "proceeds_aud",
+		"cost_aud", "gain_aud", "discountable", "reference"}); err != nil {
+		return err
+	}
+
+	for _, event := range r.Events {
+		if err := cw.Write([]string{
+			event.Asset,
+			event.Acquired.In(financialYearLocation).Format("2006-01-02"),
+			event.Disposed.In(financialYearLocation).Format("2006-01-02"),
+			strconv.FormatFloat(event.Quantity, 'f', -1, 64),
+			strconv.FormatFloat(event.Proceeds, 'f', 2, 64),
+			strconv.FormatFloat(event.Cost, 'f', 2, 64),
+			strconv.FormatFloat(event.Gain, 'f', 2, 64),
+			strconv.FormatBool(event.Discountable),
+			event.Reference,
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// Summary will describe the report in a human readable form
+func (r *TaxYearReport) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Financial year 1 July %d to 30 June %d\n", r.Year-1, r.Year)
+	fmt.Fprintf(&b, "  CGT events:                     %d\n", len(r.Events))
+	fmt.Fprintf(&b, "  Gains held under 12 months:     $%.2f\n", r.Gains)
+	fmt.Fprintf(&b, "  Gains held 12 months or more:   $%.2f\n", r.DiscountableGains)
+	fmt.Fprintf(&b, "  Capital losses:                 $%.2f\n", r.Losses)
+	fmt.Fprintf(&b, "  Losses brought forward:         $%.2f\n", r.LossesBroughtForward)
+	fmt.Fprintf(&b, "  Net capital gain:               $%.2f\n", r.NetCapitalGain)
+	fmt.Fprintf(&b, "  Losses carried forward:         $%.2f\n", r.LossesCarriedForward)
+
+	return b.String()
+}
+
+func absFloat(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
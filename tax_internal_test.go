@@ -0,0 +1,71 @@
+package goswyftx
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChartPriceSource(t *testing.T) {
+	day := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	var requests int
+	c := &Client{ctx: context.Background(), httpConn: &http.Client{Transport: roundTripFunc(
+		func(req *http.Request) (*http.Response, error) {
+			requests++
+			if !strings.Contains(req.URL.Path, "/AUD/BTC/1h/") {
+				t.Errorf("unexpected request %s", req.URL)
+			}
+			// an hourly bar closing at 100 plus the hour for the first three hours of the day
+			var bars []string
+			for hour := 0; hour < 3; hour++ {
+				bars = append(bars, fmt.Sprintf(`{"time": "%d", "close": "%d"}`,
+					day.Add(time.Duration(hour)*time.Hour).Unix(), 100+hour))
+			}
+			body := "[" + strings.Join(bars, ",") + "]"
+			return &http.Response{StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		})}}
+	prices := NewChartPriceSource(c.Chart(), "AUD")
+
+	tests := []struct {
+		at    time.Time
+		price float64
+		err   bool
+	}{
+		{at: day, price: 100},
+		{at: day.Add(59 * time.Minute), price: 100},
+		// 11:30am on the 1st of March in Sydney
+		{at: day.Add(30 * time.Minute).In(financialYearLocation), price: 100},
+		{at: day.Add(time.Hour), price: 101},
+		{at: day.Add(2*time.Hour + 30*time.Minute), price: 102},
+		{at: day.Add(3*time.Hour + time.Minute), err: true},
+	}
+
+	for _, test := range tests {
+		price, err := prices.Price("BTC", test.at)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %g", test.at, price)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.at, err.Error())
+			continue
+		}
+		if price != test.price {
+			t.Errorf("%s: expected %g, got %g", test.at, test.price, price)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected the day of bars to be fetched once, got %d requests", requests)
+	}
+	if price, err := prices.Price("aud", day); err != nil || price != 1 {
+		t.Errorf("expected the quote asset to have a price of 1, got %g and %v", price, err)
+	}
+}
@@ -0,0 +1,258 @@
+package goswyftx_test
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshturge/goswyftx"
+)
+
+type fixedPrices map[string]float64
+
+func (p fixedPrices) Price(asset string, at time.Time) (float64, error) {
+	price, ok := p[asset]
+	if !ok {
+		return 0, fmt.Errorf("no price for %s", asset)
+	}
+
+	return price, nil
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+func TestTaxReporterReports(t *testing.T) {
+	type year struct {
+		year                              int
+		gains, discountable, losses       float64
+		broughtForward, net, carryForward float64
+	}
+	tests := []struct {
+		name      string
+		trades    []*goswyftx.Trade
+		transfers []*goswyftx.Transfer
+		years     []year
+	}{
+		{
+			name: "held under 12 months",
+			trades: []*goswyftx.Trade{
+				{Time: date(2019, time.January, 10), Asset: "BTC", Quantity: 1, Price: 100},
+				{Time: date(2019, time.December, 1), Asset: "BTC", Quantity: -1, Price: 300},
+			},
+			years: []year{{year: 2020, gains: 200, net: 200}},
+		},
+		{
+			name: "held over 12 months is discounted",
+			trades: []*goswyftx.Trade{
+				{Time: date(2019, time.January, 10), Asset: "BTC", Quantity: 1, Price: 100},
+				{Time: date(2020, time.March, 1), Asset: "BTC", Quantity: -1, Price: 300},
+			},
+			years: []year{{year: 2020, discountable: 200, net: 100}},
+		},
+		{
+			name: "12 months to the day isn't discounted",
+			trades: []*goswyftx.Trade{
+				{Time: date(2019, time.January, 10), Asset: "BTC", Quantity: 2, Price: 100},
+				{Time: date(2020, time.January, 10), Asset: "BTC", Quantity: -1, Price: 300},
+				{Time: date(2020, time.January, 11), Asset: "BTC", Quantity: -1, Price: 300},
+			},
+			years: []year{{year: 2020, gains: 200, discountable: 200, net: 300}},
+		},
+		{
+			name: "held days are counted in Sydney",
+			trades: []*goswyftx.Trade{
+				// 7am on the 11th of January in Sydney
+				{Time: time.Date(2019, time.January, 10, 20, 0, 0, 0, time.UTC), Asset: "BTC",
+					Quantity: 1, Price: 100},
+				// 9pm on the 11th of January in Sydney, more than a year later in UTC
+				{Time: time.Date(2020, time.January, 11, 10, 0, 0, 0, time.UTC), Asset: "BTC",
+					Quantity: -1, Price: 300},
+			},
+			years: []year{{year: 2020, gains: 200, net: 200}},
+		},
+		{
+			name: "acquired on a leap day",
+			trades: []*goswyftx.Trade{
+				{Time: date(2020, time.February, 29), Asset: "BTC", Quantity: 1, Price: 100},
+				{Time: date(2021, time.March, 1), Asset: "BTC", Quantity: -1, Price: 300},
+			},
+			years: []year{{year: 2021, discountable: 200, net: 100}},
+		},
+		{
+			name: "losses are applied before the discount",
+			trades: []*goswyftx.Trade{
+				{Time: date(2019, time.January, 10), Asset: "BTC", Quantity: 1, Price: 100},
+				{Time: date(2020, time.August, 1), Asset: "ETH", Quantity: 1, Price: 100},
+				{Time: date(2020, time.August, 2), Asset: "XRP", Quantity: 1, Price: 200},
+				{Time: date(2020, time.September, 1), Asset: "BTC", Quantity: -1, Price: 300},
+				{Time: date(2020, time.October, 1), Asset: "ETH", Quantity: -1, Price: 200},
+				{Time: date(2020, time.November, 1), Asset: "XRP", Quantity: -1, Price: 50},
+			},
+			// the 150 loss uses up the 100 gain then 50 of the discountable gain
+			years: []year{{year: 2021, gains: 100, discountable: 200, losses: 150, net: 75}},
+		},
+		{
+			name: "losses are carried forward",
+			trades: []*goswyftx.Trade{
+				{Time: date(2019, time.January, 10), Asset: "BTC", Quantity: 2, Price: 100},
+				{Time: date(2019, time.March, 1), Asset: "BTC", Quantity: -1, Price: 50},
+				{Time: date(2020, time.August, 1), Asset: "BTC", Quantity: -1, Price: 400},
+			},
+			years: []year{
+				{year: 2019, losses: 50, carryForward: 50},
+				{year: 2021, discountable: 300, broughtForward: 50, net: 125},
+			},
+		},
+		{
+			name: "financial years start on the 1st of July in Sydney",
+			trades: []*goswyftx.Trade{
+				{Time: date(2020, time.January, 1), Asset: "BTC", Quantity: 2, Price: 100},
+				// 11:59pm on the 30th of June in Sydney
+				{Time: time.Date(2020, time.June, 30, 13, 59, 0, 0, time.UTC), Asset: "BTC",
+					Quantity: -1, Price: 200},
+				// midnight on the 1st of July in Sydney
+				{Time: time.Date(2020, time.June, 30, 14, 0, 0, 0, time.UTC), Asset: "BTC",
+					Quantity: -1, Price: 300},
+			},
+			years: []year{{year: 2020, gains: 100, net: 100}, {year: 2021, gains: 200, net: 200}},
+		},
+		{
+			name: "transfers keep their acquisition date",
+			trades: []*goswyftx.Trade{
+				{Time: date(2019, time.January, 10), Asset: "BTC", Quantity: 1, Price: 100},
+				{Time: date(2020, time.March, 1), Asset: "BTC", Quantity: -1, Price: 300},
+			},
+			transfers: []*goswyftx.Transfer{
+				{Time: date(2019, time.May, 1), Asset: "BTC", Quantity: -1},
+				{Time: date(2019, time.June, 1), Asset: "BTC", Quantity: 1},
+			},
+			years: []year{{year: 2020, discountable: 200, net: 100}},
+		},
+	}
+
+	for _, test := range tests {
+		reporter := goswyftx.NewTaxReporter(fixedPrices{}, goswyftx.FIFO)
+		reports, err := reporter.Reports(test.trades, test.transfers)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+		if len(reports) != len(test.years) {
+			t.Errorf("%s: expected %d reports, got %d", test.name, len(test.years), len(reports))
+			continue
+		}
+
+		for i, expected := range test.years {
+			report := reports[i]
+			got := year{report.Year, report.Gains, report.DiscountableGains, report.Losses,
+				report.LossesBroughtForward, report.NetCapitalGain, report.LossesCarriedForward}
+			if got.year != expected.year || !near(got.gains, expected.gains) ||
+				!near(got.discountable, expected.discountable) ||
+				!near(got.losses, expected.losses) ||
+				!near(got.broughtForward, expected.broughtForward) ||
+				!near(got.net, expected.net) || !near(got.carryForward, expected.carryForward) {
+				t.Errorf("%s: expected %+v, got %+v", test.name, expected, got)
+			}
+		}
+	}
+}
+
+func TestTaxReporterSwap(t *testing.T) {
+	trades := []*goswyftx.Trade{
+		{Time: date(2020, time.August, 1), Asset: "BTC", Quantity: 1.001, Price: 100},
+		// 10 ETH bought with 1 BTC worth 500 AUD, paying a fee of 0.001 BTC
+		{Time: date(2020, time.September, 1), Asset: "ETH", Quantity: 10, Price: 0.1, Fee: 0.001,
+			Quote: "BTC"},
+		// 10 ETH sold for 1.2 BTC worth 600 AUD, paying a fee of 0.002 BTC
+		{Time: date(2020, time.October, 1), Asset: "ETH", Quantity: -10, Price: 0.12,
+			Fee: 0.002, Quote: "BTC"},
+		{Time: date(2020, time.November, 1), Asset: "BTC", Quantity: -1.198, Price: 600},
+	}
+	reporter := goswyftx.NewTaxReporter(fixedPrices{"BTC": 500}, goswyftx.FIFO)
+	reports, err := reporter.Reports(trades, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || len(reports[0].Events) != 3 {
+		t.Fatalf("expected 1 report with 3 events, got %+v", reports)
+	}
+
+	expected := []struct {
+		asset                          string
+		quantity, proceeds, cost, gain float64
+	}{
+		// the swap disposes of the BTC spent and the BTC fee, which comes off the proceeds
+		{"BTC", 1.001, 500, 100.1, 399.9},
+		{"ETH", 10, 599, 500, 99},
+		// only the BTC left after the fee was acquired
+		{"BTC", 1.198, 718.8, 599, 119.8},
+	}
+	for i, e := range expected {
+		event := reports[0].Events[i]
+		if event.Asset != e.asset || !near(event.Quantity, e.quantity) ||
+			!near(event.Proceeds, e.proceeds) || !near(event.Cost, e.cost) ||
+			!near(event.Gain, e.gain) {
+			t.Errorf("event %d: expected %+v, got %+v", i, e, event)
+		}
+	}
+
+	// the fee left no more BTC to dispose of
+	trades = append(trades, &goswyftx.Trade{Time: date(2020, time.December, 1), Asset: "BTC",
+		Quantity: -0.002, Price: 600})
+	if _, err = reporter.Reports(trades, nil); err == nil {
+		t.Error("expected disposing of BTC spent on fees to fail")
+	}
+}
+
+func TestTaxYearReportCSVDates(t *testing.T) {
+	// 10pm UTC on the 30th of June is the 1st of July in Sydney
+	disposed := time.Date(2021, time.June, 30, 22, 0, 0, 0, time.UTC)
+	report := &goswyftx.TaxYearReport{Events: []*goswyftx.CGTEvent{
+		{Asset: "BTC", Acquired: disposed.Add(-24 * time.Hour), Disposed: disposed},
+	}}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if row := strings.Split(buf.String(), "\n")[1]; !strings.HasPrefix(row,
+		"BTC,2021-06-30,2021-07-01,") {
+		t.Errorf("expected Sydney dates, got %q", row)
+	}
+}
+
+func TestTransfersFromHistory(t *testing.T) {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	transfers, err := goswyftx.TransfersFromHistory("btc", []*goswyftx.CurrencyHistory{
+		{ID: 1, Time: goswyftx.SwyftxTime{Time: start.Add(2 * time.Hour)}, Quantity: "0.5",
+			Status: "Completed"},
+		{ID: 2, Time: goswyftx.SwyftxTime{Time: start}, Quantity: "1", Status: "Pending"},
+	}, []*goswyftx.CurrencyHistory{
+		{ID: 3, Time: goswyftx.SwyftxTime{Time: start.Add(time.Hour)}, Quantity: "0.25",
+			Status: "Completed"},
+		{ID: 4, Time: goswyftx.SwyftxTime{Time: start}, Quantity: "1", Status: "Failed"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(transfers) != 2 {
+		t.Fatalf("expected 2 transfers, got %d", len(transfers))
+	}
+	if transfers[0].Reference != "3" || transfers[0].Quantity != -0.25 ||
+		transfers[0].Asset != "BTC" {
+		t.Errorf("expected the withdrawal first, got %+v", transfers[0])
+	}
+	if transfers[1].Reference != "1" || transfers[1].Quantity != 0.5 {
+		t.Errorf("expected the deposit second, got %+v", transfers[1])
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
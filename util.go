@@ -62,3 +62,22 @@ func (s *SwyftxTime) UnmarshalJSON(b []byte) (err error) {
 
 	return nil
 }
+
+// parseFloat will parse a decimal string returned by swyftx, an empty string is zero
+func parseFloat(s string) (float64, error) {
+	if isEmptyStr(s) {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,317 @@
+package goswyftx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by a withdrawal workflow
+var (
+	ErrWithdrawDisabled = errors.New("withdrawals are disabled for the asset")
+	ErrWithdrawalFailed = errors.New("withdrawal failed")
+)
+
+// defaultWithdrawalPoll is how often withdrawal history is checked when tracking a withdrawal
+const defaultWithdrawalPoll = 30 * time.Second
+
+// WithdrawalState is how far a withdrawal has progressed
+type WithdrawalState string
+
+// Withdrawal states
+const (
+	WithdrawalCreated              WithdrawalState = "created"
+	WithdrawalChecked              WithdrawalState = "checked"
+	WithdrawalSubmitted            WithdrawalState = "submitted"
+	WithdrawalAwaitingVerification WithdrawalState = "awaiting_verification"
+	WithdrawalVerified             WithdrawalState = "verified"
+	WithdrawalPending              WithdrawalState = "pending"
+	WithdrawalCompleted            WithdrawalState = "completed"
+	WithdrawalFailed               WithdrawalState = "failed"
+)
+
+// WithdrawalEvent is a change in the state of a withdrawal
+type WithdrawalEvent struct {
+	Time time.Time
+	From WithdrawalState
+	To   WithdrawalState
+	// History is the withdrawal's history event once it has been found
+	History *CurrencyHistory
+	// Err is why the withdrawal failed
+	Err error
+}
+
+// WithdrawalConfig is a withdrawal to make
+type WithdrawalConfig struct {
+	// Asset code to withdraw e.g. BTC
+	Asset string
+	// AddressID of the saved address to withdraw to
+	AddressID int
+	Amount    float64
+	// Verify is called once the withdrawal is submitted and returns the token from the
+	// verification email, if it is nil the verification step is skipped
+	Verify func(ctx context.Context) (string, error)
+	// PollInterval is how often withdrawal history is checked, defaults to 30 seconds
+	PollInterval time.Duration
+	// OnEvent is called every time the withdrawal changes state
+	OnEvent func(event WithdrawalEvent)
+	// OnError is called when withdrawal history can't be checked while tracking the
+	// withdrawal, it is checked again on the next poll. It is optional
+	OnError func(err error)
+}
+
+// Withdrawal walks a withdrawal through checking, submitting, verifying and tracking it in the
+// asset's withdrawal history until it completes or fails
+type Withdrawal struct {
+	client *Client
+	cfg    WithdrawalConfig
+
+	mu      sync.Mutex
+	started bool
+	state   WithdrawalState
+	history *CurrencyHistory
+	asset   *MarketAsset
+}
+
+// NewWithdrawal will create a withdrawal workflow, nothing is withdrawn until Run is called
+func NewWithdrawal(c *Client, cfg WithdrawalConfig) (*Withdrawal, error) {
+	if isEmptyStr(cfg.Asset) {
+		return nil, errAssetCode
+	}
+	if cfg.Amount <= 0 {
+		return nil, errors.New("withdrawal amount must be positive")
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultWithdrawalPoll
+	}
+
+	return &Withdrawal{client: c, cfg: cfg, state: WithdrawalCreated}, nil
+}
+
+// State of the withdrawal
+func (w *Withdrawal) State() WithdrawalState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.state
+}
+
+// History is the withdrawal's history event, it is nil until the withdrawal has been found in
+// the asset's withdrawal history
+func (w *Withdrawal) History() *CurrencyHistory {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.history
+}
+
+// Run will check, submit, verify and track the withdrawal until it completes, fails or ctx is
+// done. A withdrawal can only be run once
+func (w *Withdrawal) Run(ctx context.Context) error {
+	w.mu.Lock()
+	if w.started {
+		w.mu.Unlock()
+		return fmt.Errorf("withdrawal has already been run, it is %s", w.State())
+	}
+	w.started = true
+	w.mu.Unlock()
+	client := w.client.WithContext(ctx)
+
+	record, err := w.check(client)
+	if err != nil {
+		return w.fail(err)
+	}
+	w.transition(WithdrawalChecked, nil, nil)
+
+	// withdrawals already in the history are remembered so the new one can be told apart
+	existing, err := client.History(w.asset.ID).Withdraw()
+	if err != nil {
+		client.withdrawal.release(record)
+		return w.fail(fmt.Errorf("could not get withdrawal history: %s", err.Error()))
+	}
+	seen := make(map[int]bool, len(existing))
+	for _, history := range existing {
+		seen[history.ID] = true
+	}
+
+	// the withdrawal has already been through the policy so it isn't checked again
+	if err = client.Funds(w.cfg.AddressID).withdraw(w.asset.ID, float32(w.cfg.Amount),
+		record); err != nil {
+		return w.fail(fmt.Errorf("could not submit withdrawal: %s", err.Error()))
+	}
+	w.transition(WithdrawalSubmitted, nil, nil)
+
+	if w.cfg.Verify != nil {
+		w.transition(WithdrawalAwaitingVerification, nil, nil)
+		token, err := w.cfg.Verify(ctx)
+		if err != nil {
+			return w.fail(fmt.Errorf("could not get verification token: %s", err.Error()))
+		}
+		if err = client.Address(w.asset.Code).VerifyWithdrawal(strings.TrimSpace(token)); err != nil {
+			return w.fail(fmt.Errorf("could not verify withdrawal: %s", err.Error()))
+		}
+		w.transition(WithdrawalVerified, nil, nil)
+	}
+
+	return w.track(ctx, client, seen)
+}
+
+// check the asset can be withdrawn, the amount is above its minimum, the address is saved
+// and in a valid format and the withdrawal passes the client's withdrawal policy. Without a
+// policy, or with one that doesn't check it, the withdrawal must be within the account's
+// remaining limit. The policy's reservation against its daily cap is returned
+func (w *Withdrawal) check(c *Client) (*withdrawalRecord, error) {
+	if err := c.killSwitch.check(); err != nil {
+		return nil, err
+	}
+	registry, err := c.Market().Registry()
+	if err != nil {
+		return nil, fmt.Errorf("could not get assets: %s", err.Error())
+	}
+	asset, ok := registry.ByCode(w.cfg.Asset)
+	if !ok {
+		return nil, fmt.Errorf("unknown asset: %s", w.cfg.Asset)
+	}
+	w.mu.Lock()
+	w.asset = asset
+	w.mu.Unlock()
+
+	if !asset.WithdrawEnabled {
+		return nil, fmt.Errorf("%w: %s", ErrWithdrawDisabled, asset.Code)
+	}
+	if w.cfg.Amount < float64(asset.MinWithdrawal) {
+		return nil, fmt.Errorf("%g %s is below the minimum withdrawal of %d", w.cfg.Amount,
+			asset.Code, asset.MinWithdrawal)
+	}
+
+	saved, err := c.Address(asset.Code).GetSaved()
+	if err != nil {
+		return nil, fmt.Errorf("could not get saved addresses: %s", err.Error())
+	}
+	var address *Address
+	for _, a := range saved {
+		if a.ID == w.cfg.AddressID {
+			address = a
+			break
+		}
+	}
+	if address == nil {
+		return nil, fmt.Errorf("no saved %s address with ID %d", asset.Code, w.cfg.AddressID)
+	}
+	if err = ValidateAddress(asset.Code, address.Details.Address,
+		address.Details.DestTag); err != nil {
+		return nil, err
+	}
+
+	record, err := c.withdrawal.check(c, w.cfg.AddressID, asset.ID, w.cfg.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if c.withdrawal != nil && c.withdrawal.cfg.CheckLimit {
+		return record, nil
+	}
+
+	req := &WithdrawalRequest{AddressID: w.cfg.AddressID, Asset: asset, Amount: w.cfg.Amount}
+	if err = valueWithdrawal(c, req); err == nil {
+		err = checkWithdrawalLimit(c, req)
+	}
+	if err != nil {
+		c.withdrawal.release(record)
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// track will poll withdrawal history for a new withdrawal of the same amount to the same
+// address and follow its status until it completes or fails
+func (w *Withdrawal) track(ctx context.Context, c *Client, seen map[int]bool) error {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		history, err := c.History(w.asset.ID).Withdraw()
+		// history errors are retried on the next poll, the withdrawal has already been made
+		if err != nil {
+			if w.cfg.OnError != nil {
+				w.cfg.OnError(fmt.Errorf("could not get withdrawal history: %s", err.Error()))
+			}
+		} else if done, err := w.update(history, seen); done {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// update will find the withdrawal in history and move it to the state of its status, it
+// returns true once the withdrawal has completed or failed
+func (w *Withdrawal) update(history []*CurrencyHistory, seen map[int]bool) (bool, error) {
+	current := w.History()
+	for _, h := range history {
+		if current != nil && h.ID == current.ID {
+			current = h
+			break
+		}
+		if current == nil && !seen[h.ID] && h.AddressID == w.cfg.AddressID {
+			quantity, err := parseFloat(h.Quantity)
+			// the amount was sent as a float32 so only compare it to float32 precision
+			if err == nil && math.Abs(math.Abs(quantity)-w.cfg.Amount) <= 1e-6*math.Max(1,
+				w.cfg.Amount) {
+				current = h
+				break
+			}
+		}
+	}
+	if current == nil {
+		return false, nil
+	}
+
+	switch ClassifyHistoryStatus(current.Status) {
+	case HistoryStatusFailed:
+		err := fmt.Errorf("%w: status %s", ErrWithdrawalFailed, current.Status)
+		w.transition(WithdrawalFailed, current, err)
+		return true, err
+	case HistoryStatusCompleted:
+		w.transition(WithdrawalCompleted, current, nil)
+		return true, nil
+	default:
+		w.transition(WithdrawalPending, current, nil)
+		return false, nil
+	}
+}
+
+func (w *Withdrawal) fail(err error) error {
+	w.transition(WithdrawalFailed, nil, err)
+	return err
+}
+
+// transition will move the withdrawal to a state and emit an event if it changed
+func (w *Withdrawal) transition(to WithdrawalState, history *CurrencyHistory, err error) {
+	w.mu.Lock()
+	from := w.state
+	w.state = to
+	if history != nil {
+		w.history = history
+	}
+	w.mu.Unlock()
+
+	if from == to || w.cfg.OnEvent == nil {
+		return
+	}
+	w.cfg.OnEvent(WithdrawalEvent{
+		Time:    time.Now(),
+		From:    from,
+		To:      to,
+		History: history,
+		Err:     err,
+	})
+}
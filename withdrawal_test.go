@@ -0,0 +1,74 @@
+package goswyftx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithdrawalUpdate(t *testing.T) {
+	var events []WithdrawalEvent
+	w, err := NewWithdrawal(nil, WithdrawalConfig{
+		Asset:     "BTC",
+		AddressID: 8,
+		Amount:    0.5,
+		OnEvent:   func(event WithdrawalEvent) { events = append(events, event) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.state = WithdrawalSubmitted
+	seen := map[int]bool{1: true}
+
+	history := []*CurrencyHistory{
+		// was already in history before the withdrawal was made
+		{ID: 1, Quantity: "0.5", AddressID: 8, Status: "Pending"},
+		// the same amount to another address
+		{ID: 2, Quantity: "0.5", AddressID: 9, Status: "Pending"},
+		// another amount to the same address
+		{ID: 3, Quantity: "0.4", AddressID: 8, Status: "Pending"},
+	}
+	if done, err := w.update(history, seen); done || err != nil {
+		t.Fatalf("expected the withdrawal to not be done, got %t %v", done, err)
+	}
+	if w.History() != nil {
+		t.Fatalf("expected no withdrawal to be matched, got %+v", w.History())
+	}
+
+	history = append(history, &CurrencyHistory{ID: 4, Quantity: "-0.50000001", AddressID: 8,
+		Status: "Pending"})
+	if done, _ := w.update(history, seen); done || w.State() != WithdrawalPending {
+		t.Fatalf("expected the withdrawal to be pending, got %s", w.State())
+	}
+	if w.History().ID != 4 {
+		t.Fatalf("expected withdrawal 4 to be matched, got %d", w.History().ID)
+	}
+
+	// once matched it is followed by ID even if another matching withdrawal shows up
+	history = []*CurrencyHistory{
+		{ID: 5, Quantity: "0.5", AddressID: 8, Status: "Completed"},
+		{ID: 4, Quantity: "0.5", AddressID: 8, Status: "Failed"},
+	}
+	done, err := w.update(history, seen)
+	if !done || !errors.Is(err, ErrWithdrawalFailed) {
+		t.Fatalf("expected the withdrawal to fail, got %t %v", done, err)
+	}
+	if w.State() != WithdrawalFailed {
+		t.Errorf("expected the withdrawal to be failed, got %s", w.State())
+	}
+
+	if len(events) != 2 || events[0].To != WithdrawalPending || events[1].To != WithdrawalFailed {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestWithdrawalRunOnce(t *testing.T) {
+	w, err := NewWithdrawal(nil, WithdrawalConfig{Asset: "BTC", Amount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.started = true
+	if err = w.Run(context.Background()); err == nil {
+		t.Error("expected a withdrawal that was already run to not run again")
+	}
+}
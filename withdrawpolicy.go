@@ -0,0 +1,432 @@
+package goswyftx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by FundsService.Withdraw when a withdrawal policy refuses a withdrawal
+var (
+	ErrAddressNotAllowed      = errors.New("withdrawal address is not in the allowlist")
+	ErrWithdrawalCapExceeded  = errors.New("withdrawal exceeds cap")
+	ErrWithdrawalLimit        = errors.New("withdrawal exceeds the remaining withdrawal limit")
+	ErrWithdrawalNotConfirmed = errors.New("withdrawal was not confirmed")
+)
+
+const (
+	// withdrawalWindow is the rolling window daily caps are measured over
+	withdrawalWindow = 24 * time.Hour
+	// withdrawalClockSkew is how far before a withdrawal was made its history event can be
+	// timestamped and still be matched to it
+	withdrawalClockSkew = time.Minute
+)
+
+// AllowedAddress is an address withdrawals can be sent to
+type AllowedAddress struct {
+	Asset   string `json:"asset"`
+	Address string `json:"address"`
+	// DestTag must match exactly, an empty tag only matches an address without one
+	DestTag string `json:"dest_tag,omitempty"`
+}
+
+// WithdrawalRequest is a withdrawal being checked by a policy
+type WithdrawalRequest struct {
+	AddressID int
+	Asset     *MarketAsset
+	Address   string
+	DestTag   string
+	Amount    float64
+	// Value of the withdrawal in the account's default currency
+	Value    float64
+	Currency string
+}
+
+// WithdrawalPolicyConfig is what a withdrawal policy allows, zero values disable a check
+// except for the allowlist which always has to match
+type WithdrawalPolicyConfig struct {
+	Allowlist []AllowedAddress
+	// MaxPerWithdrawal and MaxPerDay cap the quantity of an asset, keyed by asset code, that
+	// can be withdrawn at once and over a rolling 24 hours
+	MaxPerWithdrawal map[string]float64
+	MaxPerDay        map[string]float64
+	// CheckLimit refuses withdrawals worth more than the account's remaining withdrawal limit
+	CheckLimit bool
+	// ConfirmAbove is the value in the account's default currency above which Confirm must
+	// return true before a withdrawal is made. Withdrawals above it are refused if Confirm is
+	// nil
+	ConfirmAbove float64
+	Confirm      func(req *WithdrawalRequest) (bool, error)
+}
+
+// WithdrawalPolicy checks every withdrawal made by a client before it is sent. It is shared
+// by every client derived from the one it was created with after it was created
+type WithdrawalPolicy struct {
+	client *Client
+	cfg    WithdrawalPolicyConfig
+
+	mu     sync.Mutex
+	recent []*withdrawalRecord
+}
+
+// withdrawalRecord is a withdrawal made through the policy, it is pending while the withdrawal
+// is being made
+type withdrawalRecord struct {
+	assetID   int
+	addressID int
+	amount    float64
+	time      time.Time
+	pending   bool
+}
+
+// NewWithdrawalPolicy will create a withdrawal policy and attach it to c
+func NewWithdrawalPolicy(c *Client, cfg WithdrawalPolicyConfig) *WithdrawalPolicy {
+	wp := &WithdrawalPolicy{client: c, cfg: cfg}
+	c.withdrawal = wp
+
+	return wp
+}
+
+// LoadAllowlist will read a JSON array of allowed addresses from a file
+func LoadAllowlist(path string) ([]AllowedAddress, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowlist []AllowedAddress
+	if err = json.Unmarshal(data, &allowlist); err != nil {
+		return nil, fmt.Errorf("could not parse allowlist: %s", err.Error())
+	}
+
+	return allowlist, nil
+}
+
+// Check will check a withdrawal of amount of an asset to a saved address against the policy
+// without withdrawing anything. The checked request is returned even when it is refused
+func (wp *WithdrawalPolicy) Check(addressID, assetID int, amount float64) (*WithdrawalRequest,
+	error) {
+	req, record, err := wp.checkWith(wp.client, addressID, assetID, amount)
+	wp.release(record)
+
+	return req, err
+}
+
+// checkWith will check a withdrawal and reserve it against the daily cap, the reservation
+// has to be committed once the withdrawal is made or released if it isn't
+func (wp *WithdrawalPolicy) checkWith(c *Client, addressID, assetID int,
+	amount float64) (*WithdrawalRequest, *withdrawalRecord, error) {
+	registry, err := c.Market().Registry()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get assets: %s", err.Error())
+	}
+	asset, ok := registry.ByID(assetID)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown asset: %d", assetID)
+	}
+	req := &WithdrawalRequest{AddressID: addressID, Asset: asset, Amount: amount}
+
+	// the address is looked up rather than trusting its ID, so a saved address that was
+	// swapped for another under the same ID is still refused
+	saved, err := c.Address(asset.Code).GetSaved()
+	if err != nil {
+		return req, nil, fmt.Errorf("could not get saved addresses: %s", err.Error())
+	}
+	for _, address := range saved {
+		if address.ID == addressID {
+			req.Address, req.DestTag = address.Details.Address, address.Details.DestTag
+			break
+		}
+	}
+	if isEmptyStr(req.Address) || !wp.allowed(asset.Code, req.Address, req.DestTag) {
+		return req, nil, fmt.Errorf("%w: %s address %d", ErrAddressNotAllowed, asset.Code,
+			addressID)
+	}
+	if err = ValidateAddress(asset.Code, req.Address, req.DestTag); err != nil {
+		return req, nil, err
+	}
+
+	var history []*CurrencyHistory
+	if _, ok := wp.cfg.MaxPerDay[strings.ToUpper(asset.Code)]; ok {
+		if history, err = wp.recentHistory(c, asset); err != nil {
+			return req, nil, err
+		}
+	}
+	record, err := wp.reserve(req, history)
+	if err != nil {
+		return req, nil, err
+	}
+	if err = wp.checkValue(c, req); err != nil {
+		wp.release(record)
+		return req, nil, err
+	}
+
+	return req, record, nil
+}
+
+// checkValue will check the withdrawal against the remaining withdrawal limit and ask for it to
+// be confirmed if it is worth more than the confirmation threshold
+func (wp *WithdrawalPolicy) checkValue(c *Client, req *WithdrawalRequest) error {
+	if !wp.cfg.CheckLimit && wp.cfg.ConfirmAbove <= 0 {
+		return nil
+	}
+	if err := valueWithdrawal(c, req); err != nil {
+		return err
+	}
+
+	if wp.cfg.CheckLimit {
+		if err := checkWithdrawalLimit(c, req); err != nil {
+			return err
+		}
+	}
+
+	return wp.confirm(req)
+}
+
+// checkWithdrawalLimit will refuse a valued withdrawal worth more than the account's remaining
+// withdrawal limit
+func checkWithdrawalLimit(c *Client, req *WithdrawalRequest) error {
+	limit, err := c.Limit().Withdrawal()
+	if err != nil {
+		return fmt.Errorf("could not get withdrawal limit: %s", err.Error())
+	}
+	if req.Value > float64(limit.Remaining) {
+		return fmt.Errorf("%w: %.2f %s is more than the %d %s remaining", ErrWithdrawalLimit,
+			req.Value, req.Currency, limit.Remaining, req.Currency)
+	}
+
+	return nil
+}
+
+// confirm will ask for a valued withdrawal to be confirmed if it is above the threshold
+func (wp *WithdrawalPolicy) confirm(req *WithdrawalRequest) error {
+	if wp.cfg.ConfirmAbove <= 0 || req.Value <= wp.cfg.ConfirmAbove {
+		return nil
+	}
+	if wp.cfg.Confirm == nil {
+		return ErrWithdrawalNotConfirmed
+	}
+	confirmed, err := wp.cfg.Confirm(req)
+	if err != nil {
+		return fmt.Errorf("could not confirm withdrawal: %s", err.Error())
+	}
+	if !confirmed {
+		return ErrWithdrawalNotConfirmed
+	}
+
+	return nil
+}
+
+func (wp *WithdrawalPolicy) allowed(asset, address, destTag string) bool {
+	for _, allowed := range wp.cfg.Allowlist {
+		if strings.EqualFold(allowed.Asset, asset) && allowed.Address == address &&
+			allowed.DestTag == destTag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recentHistory is the asset's withdrawal history over the last 24 hours
+func (wp *WithdrawalPolicy) recentHistory(c *Client, asset *MarketAsset) ([]*CurrencyHistory,
+	error) {
+	var history []*CurrencyHistory
+	it := c.History(asset.ID).IterateWithdrawals(HistoryQuery{From: time.Now().Add(
+		-withdrawalWindow)})
+	for it.Next() {
+		history = append(history, it.History())
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("could not get withdrawal history: %s", err.Error())
+	}
+
+	return history, nil
+}
+
+// reserve will check the withdrawal against the caps and hold its amount against the daily
+// cap until it is committed or released, so concurrent withdrawals can't both pass the cap
+func (wp *WithdrawalPolicy) reserve(req *WithdrawalRequest,
+	history []*CurrencyHistory) (*withdrawalRecord, error) {
+	code := strings.ToUpper(req.Asset.Code)
+	if max, ok := wp.cfg.MaxPerWithdrawal[code]; ok && req.Amount > max {
+		return nil, fmt.Errorf("%w: %g %s is more than %g per withdrawal",
+			ErrWithdrawalCapExceeded, req.Amount, code, max)
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	now := time.Now()
+	wp.prune(now)
+	if max, ok := wp.cfg.MaxPerDay[code]; ok {
+		withdrawn, err := withdrawnSince(history, wp.recent, req.Asset.ID,
+			now.Add(-withdrawalWindow))
+		if err != nil {
+			return nil, err
+		}
+		if withdrawn+req.Amount > max {
+			return nil, fmt.Errorf("%w: %g %s already withdrawn today, the daily cap is %g",
+				ErrWithdrawalCapExceeded, withdrawn, code, max)
+		}
+	}
+
+	record := &withdrawalRecord{
+		assetID:   req.Asset.ID,
+		addressID: req.AddressID,
+		amount:    req.Amount,
+		time:      now,
+		pending:   true,
+	}
+	wp.recent = append(wp.recent, record)
+
+	return record, nil
+}
+
+// withdrawnSince is the quantity of an asset withdrawn since a time. Withdrawal history can lag
+// behind a withdrawal that was just made, so withdrawals made through the policy that aren't
+// in history yet are added to it. History that isn't from the policy, like withdrawals made
+// from the website, is counted as well
+func withdrawnSince(history []*CurrencyHistory, records []*withdrawalRecord, assetID int,
+	since time.Time) (float64, error) {
+	var withdrawn float64
+	quantities := make([]float64, len(history))
+	for i, h := range history {
+		if h == nil || h.Time.Before(since) {
+			continue
+		}
+		quantity, err := parseFloat(h.Quantity)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse withdrawal quantity: %s", err.Error())
+		}
+		quantities[i] = math.Abs(quantity)
+		if ClassifyHistoryStatus(h.Status) != HistoryStatusFailed {
+			withdrawn += quantities[i]
+		}
+	}
+
+	matched := make([]bool, len(history))
+	for _, record := range records {
+		if record.assetID != assetID || (!record.pending && record.time.Before(since)) {
+			continue
+		}
+		if i := matchRecord(history, quantities, matched, record); i >= 0 {
+			// already counted, or it failed and doesn't count at all
+			matched[i] = true
+			continue
+		}
+		withdrawn += record.amount
+	}
+
+	return withdrawn, nil
+}
+
+// matchRecord will find the index of the history event of a withdrawal made through the
+// policy, or -1 if it isn't in history yet
+func matchRecord(history []*CurrencyHistory, quantities []float64, matched []bool,
+	record *withdrawalRecord) int {
+	if record.pending {
+		return -1
+	}
+	for i, h := range history {
+		if h == nil || matched[i] || h.AddressID != record.addressID ||
+			h.Time.Before(record.time.Add(-withdrawalClockSkew)) {
+			continue
+		}
+		// withdrawals are sent as a float32 so only compare to float32 precision
+		if math.Abs(quantities[i]-record.amount) <= 1e-6*math.Max(1, record.amount) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// valueWithdrawal will price a withdrawal in the account's default currency
+func valueWithdrawal(c *Client, req *WithdrawalRequest) error {
+	profile, err := c.Account().Profile()
+	if err != nil {
+		return fmt.Errorf("could not get profile: %s", err.Error())
+	}
+	req.Currency = profile.Currency.Code
+
+	if req.Asset.ID == profile.Currency.ID {
+		req.Value = req.Amount
+		return nil
+	}
+
+	rates, err := c.Market().Rates(profile.Currency.ID)
+	if err != nil {
+		return fmt.Errorf("could not get live rates: %s", err.Error())
+	}
+	rate, ok := rates[req.Asset.ID]
+	if !ok {
+		return fmt.Errorf("no live rate for %s", req.Asset.Code)
+	}
+	price, err := parseFloat(rate.MidPrice)
+	if err != nil {
+		return fmt.Errorf("could not parse mid price of %s: %s", req.Asset.Code, err.Error())
+	}
+	req.Value = req.Amount * price
+
+	return nil
+}
+
+// check a withdrawal made by c and reserve it against the daily cap, a nil policy allows
+// everything
+func (wp *WithdrawalPolicy) check(c *Client, addressID, assetID int,
+	amount float64) (*withdrawalRecord, error) {
+	if wp == nil {
+		return nil, nil
+	}
+
+	_, record, err := wp.checkWith(c, addressID, assetID, amount)
+	return record, err
+}
+
+// commit a reserved withdrawal once it has been made, it keeps counting towards the daily cap
+func (wp *WithdrawalPolicy) commit(record *withdrawalRecord) {
+	if wp == nil || record == nil {
+		return
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	record.pending = false
+	record.time = time.Now()
+}
+
+// release a reserved withdrawal that wasn't made
+func (wp *WithdrawalPolicy) release(record *withdrawalRecord) {
+	if wp == nil || record == nil {
+		return
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	for i, r := range wp.recent {
+		if r == record {
+			wp.recent = append(wp.recent[:i], wp.recent[i+1:]...)
+			return
+		}
+	}
+}
+
+// prune will drop committed withdrawals older than the daily cap's window
+func (wp *WithdrawalPolicy) prune(now time.Time) {
+	since := now.Add(-withdrawalWindow)
+	recent := wp.recent[:0]
+	for _, record := range wp.recent {
+		if record.pending || !record.time.Before(since) {
+			recent = append(recent, record)
+		}
+	}
+	wp.recent = recent
+}
@@ -0,0 +1,132 @@
+package goswyftx
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWithdrawalPolicyAllowed(t *testing.T) {
+	wp := &WithdrawalPolicy{cfg: WithdrawalPolicyConfig{Allowlist: []AllowedAddress{
+		{Asset: "btc", Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},
+		{Asset: "XRP", Address: "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", DestTag: "42"},
+	}}}
+
+	tests := []struct {
+		asset, address, destTag string
+		allowed                 bool
+	}{
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", true},
+		{"LTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", false},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "42", true},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "", false},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "43", false},
+	}
+	for _, test := range tests {
+		if allowed := wp.allowed(test.asset, test.address, test.destTag); allowed != test.allowed {
+			t.Errorf("%s %s tag %q: expected allowed to be %t", test.asset, test.address,
+				test.destTag, test.allowed)
+		}
+	}
+}
+
+func TestWithdrawalPolicyReserve(t *testing.T) {
+	btc := &MarketAsset{ID: 3, Code: "BTC"}
+	wp := &WithdrawalPolicy{cfg: WithdrawalPolicyConfig{
+		MaxPerWithdrawal: map[string]float64{"BTC": 1},
+		MaxPerDay:        map[string]float64{"BTC": 1.5},
+	}}
+
+	if _, err := wp.reserve(&WithdrawalRequest{Asset: btc, Amount: 2}, nil); !errors.Is(err,
+		ErrWithdrawalCapExceeded) {
+		t.Errorf("expected the per withdrawal cap to be exceeded, got %v", err)
+	}
+
+	// a withdrawal made from the website counts towards the cap
+	history := []*CurrencyHistory{
+		{ID: 1, Time: SwyftxTime{time.Now().Add(-time.Hour)}, Quantity: "0.5", AddressID: 7,
+			Status: "Completed"},
+	}
+	first, err := wp.reserve(&WithdrawalRequest{Asset: btc, AddressID: 8, Amount: 0.75}, history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first withdrawal is still being made, its reservation stops a concurrent one
+	if _, err = wp.reserve(&WithdrawalRequest{Asset: btc, AddressID: 8, Amount: 0.5},
+		history); !errors.Is(err, ErrWithdrawalCapExceeded) {
+		t.Errorf("expected the reservation to count towards the daily cap, got %v", err)
+	}
+
+	wp.release(first)
+	second, err := wp.reserve(&WithdrawalRequest{Asset: btc, AddressID: 8, Amount: 0.5}, history)
+	if err != nil {
+		t.Fatalf("expected a released reservation to not count, got %v", err)
+	}
+	wp.commit(second)
+	if _, err = wp.reserve(&WithdrawalRequest{Asset: btc, AddressID: 8, Amount: 0.75},
+		history); !errors.Is(err, ErrWithdrawalCapExceeded) {
+		t.Errorf("expected a committed withdrawal to count towards the daily cap, got %v", err)
+	}
+}
+
+func TestWithdrawnSince(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-withdrawalWindow)
+	records := []*withdrawalRecord{
+		// in history below
+		{assetID: 3, addressID: 8, amount: 0.3, time: now.Add(-2 * time.Hour)},
+		// not in history yet
+		{assetID: 3, addressID: 8, amount: 0.2, time: now.Add(-time.Minute)},
+		// failed in history
+		{assetID: 3, addressID: 9, amount: 1, time: now.Add(-3 * time.Hour)},
+		// another asset
+		{assetID: 4, addressID: 8, amount: 5, time: now},
+		{assetID: 3, addressID: 8, amount: 0.1, time: now, pending: true},
+	}
+	history := []*CurrencyHistory{
+		{ID: 1, Time: SwyftxTime{now.Add(-time.Hour)}, Quantity: "0.5", AddressID: 7,
+			Status: "Completed"},
+		{ID: 2, Time: SwyftxTime{now.Add(-2 * time.Hour)}, Quantity: "-0.30000001",
+			AddressID: 8, Status: "Completed"},
+		{ID: 3, Time: SwyftxTime{now.Add(-3 * time.Hour)}, Quantity: "1", AddressID: 9,
+			Status: "Failed"},
+		{ID: 4, Time: SwyftxTime{now.Add(-48 * time.Hour)}, Quantity: "10", AddressID: 7,
+			Status: "Completed"},
+	}
+
+	withdrawn, err := withdrawnSince(history, records, 3, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 0.5 + 0.3 + 0.2 + 0.1; math.Abs(withdrawn-expected) > 1e-6 {
+		t.Errorf("expected %f withdrawn, got %f", expected, withdrawn)
+	}
+}
+
+func TestWithdrawalPolicyConfirm(t *testing.T) {
+	var asked int
+	tests := []struct {
+		value   float64
+		confirm func(req *WithdrawalRequest) (bool, error)
+		err     error
+		asked   int
+	}{
+		{100, nil, nil, 0},
+		{1000, nil, ErrWithdrawalNotConfirmed, 0},
+		{1000, func(*WithdrawalRequest) (bool, error) { asked++; return false, nil },
+			ErrWithdrawalNotConfirmed, 1},
+		{1000, func(*WithdrawalRequest) (bool, error) { asked++; return true, nil }, nil, 1},
+	}
+	for i, test := range tests {
+		asked = 0
+		wp := &WithdrawalPolicy{cfg: WithdrawalPolicyConfig{ConfirmAbove: 500,
+			Confirm: test.confirm}}
+		if err := wp.confirm(&WithdrawalRequest{Value: test.value}); !errors.Is(err, test.err) {
+			t.Errorf("test %d: expected error %v, got %v", i, test.err, err)
+		}
+		if asked != test.asked {
+			t.Errorf("test %d: expected to be asked %d times, got %d", i, test.asked, asked)
+		}
+	}
+}